@@ -0,0 +1,21 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/pixdesc.h>
+import "C"
+
+// hasAlpha returns true if the pixel
+// format has an alpha channel.
+func hasAlpha(format C.enum_AVPixelFormat) bool {
+	desc := C.av_pix_fmt_desc_get(format)
+
+	return desc != nil && desc.flags&C.AV_PIX_FMT_FLAG_ALPHA != 0
+}
+
+// fillOpaque sets the alpha
+// of the RGBA pixels to 255.
+func fillOpaque(pix []byte) {
+	for i := 3; i < len(pix); i += 4 {
+		pix[i] = 0xFF
+	}
+}
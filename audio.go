@@ -5,17 +5,17 @@ package reisen
 // #include <libavformat/avformat.h>
 // #include <libavutil/avutil.h>
 // #include <libswresample/swresample.h>
-// AVChannelLayout stereo = AV_CHANNEL_LAYOUT_STEREO;
 import "C"
 import (
 	"fmt"
+	"time"
 	"unsafe"
 )
 
 const (
-	// StandardChannelCount is used for
-	// audio conversion while decoding
-	// audio frames.
+	// StandardChannelCount is the number
+	// of channels of the audio frames
+	// decoded by Open.
 	StandardChannelCount = 2
 )
 
@@ -26,6 +26,19 @@ type AudioStream struct {
 	swrCtx     *C.SwrContext
 	buffer     *C.uint8_t
 	bufferSize C.int
+	dstRate    C.int
+	dstFormat  C.enum_AVSampleFormat
+	dstLayout  C.AVChannelLayout
+	srcLayout  C.AVChannelLayout
+	srcFormat  C.enum_AVSampleFormat
+	srcRate    C.int
+	seekTarget int64
+	clipping   ClippingStats
+	// The encoder delay and padding
+	// from the packet side data.
+	packetDelay   int
+	packetPadding int
+	audioFilter   *AudioFilterGraph
 }
 
 // ChannelCount returns the number of channels
@@ -48,36 +61,241 @@ func (audio *AudioStream) FrameSize() int {
 
 // Open opens the audio stream to decode
 // audio frames and samples from it.
+//
+// The samples are converted to the stereo
+// 16-bit samples of the source sample rate.
 func (audio *AudioStream) Open() error {
+	return audio.OpenWithFormat(SampleFormatS16,
+		ChannelLayoutStereo, 0)
+}
+
+// OpenWithLayout opens the audio stream to decode
+// audio frames and samples from it converting them
+// to the 16-bit samples of the specified channel
+// layout (e.g., ChannelLayout5Point1 or
+// ChannelLayoutSource to keep the original one).
+func (audio *AudioStream) OpenWithLayout(channelLayout ChannelLayout) error {
+	return audio.OpenWithFormat(SampleFormatS16,
+		channelLayout, 0)
+}
+
+// OpenResample opens the audio stream to decode
+// audio frames and samples from it resampling
+// them to the stereo 16-bit samples of the
+// specified sample rate (e.g., 48000 Hz).
+func (audio *AudioStream) OpenResample(sampleRate int) error {
+	if sampleRate <= 0 {
+		return fmt.Errorf(
+			"invalid sample rate %d", sampleRate)
+	}
+
+	return audio.OpenWithFormat(SampleFormatS16,
+		ChannelLayoutStereo, sampleRate)
+}
+
+// OpenWithFormat opens the audio stream to decode
+// audio frames and samples from it converting them
+// to the specified sample format, channel layout
+// (ChannelLayoutSource means the layout of the
+// source) and sample rate (0 means the rate of
+// the source).
+func (audio *AudioStream) OpenWithFormat(sampleFmt SampleFormat, channelLayout ChannelLayout, sampleRate int) error {
+	if sampleRate < 0 {
+		return fmt.Errorf(
+			"invalid sample rate %d", sampleRate)
+	}
+
+	// The output format is checked against the
+	// parameters of the stream before the codec
+	// is opened, so nothing is left open on error.
+	C.av_channel_layout_uninit(&audio.dstLayout)
+	var status C.int
+
+	if channelLayout == ChannelLayoutSource {
+		status = C.av_channel_layout_copy(
+			&audio.dstLayout, &audio.codecParams.ch_layout)
+	} else {
+		status = C.av_channel_layout_from_mask(
+			&audio.dstLayout, C.uint64_t(channelLayout))
+	}
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't set the channel layout")
+	}
+
+	if audio.dstLayout.nb_channels <= 0 {
+		return fmt.Errorf(
+			"the channel layout has no channels")
+	}
+
 	err := audio.open()
 
 	if err != nil {
 		return err
 	}
 
-	C.swr_alloc_set_opts2(&audio.swrCtx,
-		&C.stereo,
-		C.AV_SAMPLE_FMT_S16,
-		audio.codecCtx.sample_rate,
-		&audio.codecCtx.ch_layout,
-		audio.codecCtx.sample_fmt,
-		audio.codecCtx.sample_rate,
+	audio.dstFormat = C.enum_AVSampleFormat(sampleFmt)
+	audio.dstRate = C.int(sampleRate)
+
+	if audio.dstRate == 0 {
+		audio.dstRate = audio.codecCtx.sample_rate
+	}
+
+	err = audio.initResampler(&audio.codecCtx.ch_layout,
+		audio.codecCtx.sample_fmt, audio.codecCtx.sample_rate)
+
+	if err != nil {
+		audio.close()
+		return err
+	}
+
+	audio.buffer = nil
+	audio.seekTarget = noPTS
+
+	return nil
+}
+
+// OutputSampleFormat returns the sample
+// format of the decoded audio frames.
+func (audio *AudioStream) OutputSampleFormat() SampleFormat {
+	return SampleFormat(audio.dstFormat)
+}
+
+// OutputChannelCount returns the number of
+// channels of the decoded audio frames.
+func (audio *AudioStream) OutputChannelCount() int {
+	return int(audio.dstLayout.nb_channels)
+}
+
+// Rewind rewinds the whole media to the
+// beginning of the audio packet containing
+// the specified time location.
+func (audio *AudioStream) Rewind(t time.Duration) error {
+	return audio.RewindWithFlags(t, SeekBackward)
+}
+
+// RewindWithFlags rewinds the whole media to
+// the specified time location based on the
+// stream with the specified seek flags.
+func (audio *AudioStream) RewindWithFlags(t time.Duration, flags SeekFlags) error {
+	return audio.rewind(t, flags)
+}
+
+// Seek rewinds the whole media to the specified
+// time location with sample accuracy: the decoded
+// samples preceding the location are discarded,
+// so the next audio frame starts exactly at it.
+//
+// It doesn't depend on a video stream, so it
+// can be used for audio-only media.
+func (audio *AudioStream) Seek(t time.Duration) error {
+	err := audio.rewind(t, SeekBackward)
+
+	if err != nil {
+		return err
+	}
+
+	tbNum, tbDen := audio.TimeBase()
+	audio.seekTarget = int64(t.Seconds() *
+		float64(tbDen) / float64(tbNum))
+
+	return nil
+}
+
+// rewind seeks the media to the audio packet
+// containing the specified time location with
+// the seek flags and resets the streams.
+func (audio *AudioStream) rewind(t time.Duration, flags SeekFlags) error {
+	if flags&SeekByte != 0 {
+		return fmt.Errorf(
+			"use SeekByte of the media to seek by bytes")
+	}
+
+	tbNum, tbDen := audio.TimeBase()
+	ts := int64(t.Seconds() * float64(tbDen) / float64(tbNum))
+
+	status := audio.media.seek(audio.inner.index,
+		ts, C.int(flags))
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't rewind the stream")
+	}
+
+	return audio.media.resetStreams()
+}
+
+// reset drops the samples buffered by the
+// decoder, the resampler and the filters of
+// the stream from the previous position.
+func (audio *AudioStream) reset() error {
+	err := audio.baseStream.reset()
+
+	if err != nil {
+		return err
+	}
+
+	audio.seekTarget = noPTS
+
+	if audio.swrCtx != nil {
+		C.swr_close(audio.swrCtx)
+		status := C.swr_init(audio.swrCtx)
+
+		if status < 0 {
+			return newAVError(status,
+				"couldn't reset the SWR context")
+		}
+	}
+
+	if audio.audioFilter != nil {
+		audio.audioFilter.reset()
+	}
+
+	return nil
+}
+
+// initResampler (re)creates the SWR context converting
+// decoded audio samples of the specified channel layout,
+// sample format and sample rate to the output samples.
+func (audio *AudioStream) initResampler(layout *C.AVChannelLayout, format C.enum_AVSampleFormat, rate C.int) error {
+	if audio.swrCtx != nil {
+		C.swr_free(&audio.swrCtx)
+		audio.swrCtx = nil
+	}
+
+	status := C.swr_alloc_set_opts2(&audio.swrCtx,
+		&audio.dstLayout,
+		audio.dstFormat,
+		audio.dstRate,
+		layout,
+		format,
+		rate,
 		0,
 		nil)
 
-	if audio.swrCtx == nil {
+	if status < 0 || audio.swrCtx == nil {
 		return fmt.Errorf(
 			"couldn't allocate an SWR context")
 	}
 
-	status := C.swr_init(audio.swrCtx)
+	status = C.swr_init(audio.swrCtx)
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't initialize the SWR context", status)
+		return newAVError(status,
+			"couldn't initialize the SWR context")
 	}
 
-	audio.buffer = nil
+	C.av_channel_layout_uninit(&audio.srcLayout)
+	status = C.av_channel_layout_copy(&audio.srcLayout, layout)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't copy the channel layout")
+	}
+
+	audio.srcFormat = format
+	audio.srcRate = rate
 
 	return nil
 }
@@ -89,6 +307,7 @@ func (audio *AudioStream) ReadFrame() (Frame, bool, error) {
 
 // ReadAudioFrame reads a new audio frame from the stream.
 func (audio *AudioStream) ReadAudioFrame() (*AudioFrame, bool, error) {
+	audio.recordSkipSamples(audio.media.packet)
 	ok, err := audio.read()
 
 	if err != nil {
@@ -104,14 +323,60 @@ func (audio *AudioStream) ReadAudioFrame() (*AudioFrame, bool, error) {
 		return nil, false, nil
 	}
 
+	if audio.audioFilter != nil {
+		return audio.readFilteredFrame()
+	}
+
+	data, pts, err := audio.convertSamples(audio.frame,
+		audio.unwrapPTS(int64(audio.frame.pts)))
+
+	if err != nil || data == nil {
+		return nil, err == nil, err
+	}
+
+	return audio.newOutputFrame(pts, data), true, nil
+}
+
+// convertSamples converts the samples of the decoded
+// frame with the specified timestamp to the output
+// format and returns them with the timestamp of the
+// first one. The samples are nil if all of them
+// precede the target of the accurate seek.
+func (audio *AudioStream) convertSamples(src *C.AVFrame, pts int64) ([]byte, int64, error) {
+	// The sample rate, the channel layout or
+	// the sample format of the source may
+	// change in the middle of the stream, so
+	// the SWR context has to be recreated.
+	srcFormat := C.enum_AVSampleFormat(src.format)
+
+	if src.sample_rate != audio.srcRate ||
+		srcFormat != audio.srcFormat ||
+		C.av_channel_layout_compare(&src.ch_layout,
+			&audio.srcLayout) != 0 {
+		err := audio.initResampler(&src.ch_layout,
+			srcFormat, src.sample_rate)
+
+		if err != nil {
+			return nil, noPTS, err
+		}
+	}
+
+	channels := int(audio.dstLayout.nb_channels)
+	maxSamples := C.swr_get_out_samples(
+		audio.swrCtx, src.nb_samples)
+
+	if maxSamples < 0 {
+		return nil, noPTS, newAVError(maxSamples,
+			"couldn't get the number of output samples")
+	}
+
 	maxBufferSize := C.av_samples_get_buffer_size(
-		nil, StandardChannelCount,
-		audio.frame.nb_samples,
-		C.AV_SAMPLE_FMT_S16, 1)
+		nil, C.int(channels), maxSamples,
+		audio.dstFormat, 1)
 
 	if maxBufferSize < 0 {
-		return nil, false, fmt.Errorf(
-			"%d: couldn't get the max buffer size", maxBufferSize)
+		return nil, noPTS, newAVError(maxBufferSize,
+			"couldn't get the max buffer size")
 	}
 
 	if maxBufferSize > audio.bufferSize {
@@ -125,28 +390,94 @@ func (audio *AudioStream) ReadAudioFrame() (*AudioFrame, bool, error) {
 		audio.bufferSize = maxBufferSize
 
 		if audio.buffer == nil {
-			return nil, false, fmt.Errorf(
+			return nil, noPTS, fmt.Errorf(
 				"couldn't allocate an AV buffer")
 		}
 	}
 
+	// The planar samples of each channel are
+	// stored one after another in the buffer.
+	sampleSize := int(C.av_get_bytes_per_sample(audio.dstFormat))
+	planar := C.av_sample_fmt_is_planar(audio.dstFormat) != 0
+	planes := []*C.uint8_t{audio.buffer}
+
+	if planar {
+		planeSize := int(maxSamples) * sampleSize
+		planes = make([]*C.uint8_t, channels)
+
+		for ch := range planes {
+			planes[ch] = (*C.uint8_t)(unsafe.Add(
+				unsafe.Pointer(audio.buffer), ch*planeSize))
+		}
+	}
+
 	gotSamples := C.swr_convert(audio.swrCtx,
-		&audio.buffer, audio.frame.nb_samples,
-		&audio.frame.data[0], audio.frame.nb_samples)
+		&planes[0], maxSamples,
+		&src.data[0], src.nb_samples)
 
 	if gotSamples < 0 {
-		return nil, false, fmt.Errorf(
-			"%d: couldn't convert the audio frame", gotSamples)
+		return nil, noPTS, newAVError(gotSamples,
+			"couldn't convert the audio frame")
 	}
 
-	data := C.GoBytes(unsafe.Pointer(
-		audio.buffer), maxBufferSize)
-	frame := newAudioFrame(audio,
-		int64(audio.frame.pts),
+	first := 0
+
+	// Discard the samples preceding
+	// the target of the accurate seek.
+	if audio.seekTarget != noPTS && pts != noPTS {
+		if pts < audio.seekTarget {
+			tbNum, tbDen := audio.TimeBase()
+			first = int((audio.seekTarget - pts) * int64(tbNum) *
+				int64(audio.dstRate) / int64(tbDen))
+
+			if first >= int(gotSamples) {
+				return nil, noPTS, nil
+			}
+
+			pts = audio.seekTarget
+		}
+
+		audio.seekTarget = noPTS
+	}
+
+	// Only the samples actually converted are copied:
+	// the rest of the buffer holds the stale samples
+	// of the previous frames.
+	var data []byte
+
+	if planar {
+		count := int(gotSamples) - first
+		data = make([]byte, 0, count*sampleSize*channels)
+
+		for _, plane := range planes {
+			samples := unsafe.Slice((*byte)(unsafe.Pointer(plane)),
+				int(gotSamples)*sampleSize)
+			data = append(data, samples[first*sampleSize:]...)
+		}
+	} else {
+		frameSize := sampleSize * channels
+		data = C.GoBytes(unsafe.Add(unsafe.Pointer(audio.buffer),
+			first*frameSize), C.int((int(gotSamples)-first)*frameSize))
+	}
+
+	if audio.dstFormat == C.AV_SAMPLE_FMT_S16 ||
+		audio.dstFormat == C.AV_SAMPLE_FMT_S16P {
+		audio.countClipping(data)
+	}
+
+	return data, pts, nil
+}
+
+// newOutputFrame returns a new audio frame
+// of the converted samples.
+func (audio *AudioStream) newOutputFrame(pts int64, data []byte) *AudioFrame {
+	frame := newAudioFrame(audio, pts,
 		int(audio.frame.coded_picture_number),
 		int(audio.frame.display_picture_number), data)
+	frame.channels = int(audio.dstLayout.nb_channels)
+	frame.format = audio.dstFormat
 
-	return frame, true, nil
+	return frame
 }
 
 // Close closes the audio stream and
@@ -162,6 +493,13 @@ func (audio *AudioStream) Close() error {
 	audio.buffer = nil
 	C.swr_free(&audio.swrCtx)
 	audio.swrCtx = nil
+	C.av_channel_layout_uninit(&audio.srcLayout)
+	C.av_channel_layout_uninit(&audio.dstLayout)
+	audio.seekTarget = noPTS
+
+	if audio.audioFilter != nil {
+		audio.audioFilter.reset()
+	}
 
 	return nil
 }
@@ -0,0 +1,216 @@
+package reisen
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testSampleRate = 8000
+	testChannels   = 2
+)
+
+// writeTestWAV writes a 16-bit stereo WAV file
+// of the specified number of samples per channel.
+// The left channel of each sample holds its index
+// and the right one holds its negated index, so
+// the position of the decoded samples is known.
+func writeTestWAV(t *testing.T, samples int) string {
+	t.Helper()
+
+	format := wavFormat{
+		channels:      testChannels,
+		sampleRate:    testSampleRate,
+		bitsPerSample: 16,
+	}
+	data := make([]byte, samples*testChannels*2)
+
+	for i := 0; i < samples; i++ {
+		binary.LittleEndian.PutUint16(data[i*4:], uint16(int16(i)))
+		binary.LittleEndian.PutUint16(data[i*4+2:], uint16(-int16(i)))
+	}
+
+	filename := filepath.Join(t.TempDir(), "test.wav")
+	content := append(format.header(uint32(len(data))), data...)
+
+	if err := os.WriteFile(filename, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return filename
+}
+
+// openTestAudio opens the only audio stream of the
+// media file decoding it to 16-bit stereo samples.
+func openTestAudio(t *testing.T, filename string) (*Media, *AudioStream) {
+	t.Helper()
+
+	media, err := NewMedia(filename)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(media.Close)
+
+	if err := media.OpenDecode(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { media.CloseDecode() })
+	audioStreams := media.AudioStreams()
+
+	if len(audioStreams) != 1 {
+		t.Fatalf("got %d audio streams, want 1", len(audioStreams))
+	}
+
+	audio := audioStreams[0]
+
+	if err := audio.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { audio.Close() })
+
+	return media, audio
+}
+
+// readTestFrame reads the next audio frame with
+// samples. It returns nil at the end of the media.
+func readTestFrame(t *testing.T, media *Media, audio *AudioStream) *AudioFrame {
+	t.Helper()
+
+	for {
+		pkt, gotPacket, err := media.ReadPacket()
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Fatal(err)
+		}
+
+		if !gotPacket {
+			return nil
+		}
+
+		if pkt == nil || pkt.StreamIndex() != audio.Index() {
+			continue
+		}
+
+		frame, gotFrame, err := audio.ReadAudioFrame()
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Fatal(err)
+		}
+
+		if !gotFrame {
+			return nil
+		}
+
+		if frame != nil && len(frame.Data()) > 0 {
+			return frame
+		}
+	}
+}
+
+// firstSample returns the left channel
+// value of the first sample of the frame.
+func firstSample(frame *AudioFrame) int {
+	return int(int16(binary.LittleEndian.Uint16(frame.Data())))
+}
+
+func TestReadAudioFrameSampleCount(t *testing.T) {
+	media, audio := openTestAudio(t, writeTestWAV(t, testSampleRate))
+	dur, err := media.Duration()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := int(dur.Seconds()*testSampleRate + 0.5)
+	total := 0
+
+	for {
+		frame := readTestFrame(t, media, audio)
+
+		if frame == nil {
+			break
+		}
+
+		size := len(frame.Data())
+
+		if size%(testChannels*2) != 0 {
+			t.Fatalf("got a frame of %d bytes, not whole samples", size)
+		}
+
+		total += size / (testChannels * 2)
+	}
+
+	if total != want {
+		t.Errorf("decoded %d samples, want %d (%v at %d Hz)",
+			total, want, dur, testSampleRate)
+	}
+}
+
+func TestRewindDropsStaleSamples(t *testing.T) {
+	media, audio := openTestAudio(t, writeTestWAV(t, 2*testSampleRate))
+	target := 500 * time.Millisecond
+	targetSample := int(target.Seconds() * testSampleRate)
+
+	// Decode past the middle of the media, so the
+	// decoder and the resampler hold later samples.
+	for {
+		frame := readTestFrame(t, media, audio)
+
+		if frame == nil {
+			t.Fatal("the media ended before the middle")
+		}
+
+		if firstSample(frame) >= 3*testSampleRate/2 {
+			break
+		}
+	}
+
+	if err := audio.Rewind(target); err != nil {
+		t.Fatal(err)
+	}
+
+	frame := readTestFrame(t, media, audio)
+
+	if frame == nil {
+		t.Fatal("no frame after rewinding")
+	}
+
+	offset, err := frame.PresentationOffset()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample := firstSample(frame)
+	wantSample := int(offset.Seconds()*testSampleRate + 0.5)
+
+	if sample > targetSample || sample != wantSample {
+		t.Errorf("got sample %d at %v after rewinding to %v, want sample %d",
+			sample, offset, target, wantSample)
+	}
+
+	// The accurate seek starts exactly at the target.
+	if err := audio.Seek(target); err != nil {
+		t.Fatal(err)
+	}
+
+	frame = readTestFrame(t, media, audio)
+
+	if frame == nil {
+		t.Fatal("no frame after seeking")
+	}
+
+	if sample := firstSample(frame); sample != targetSample {
+		t.Errorf("got sample %d after seeking to %v, want %d",
+			sample, target, targetSample)
+	}
+}
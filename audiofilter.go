@@ -0,0 +1,242 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/channel_layout.h>
+// #include <libavutil/frame.h>
+// #include <libavutil/mathematics.h>
+// #include <libavutil/samplefmt.h>
+import "C"
+import "fmt"
+
+// AudioFilterGraph is a libavfilter graph processing
+// the decoded frames of an audio stream (e.g., with
+// the atempo, volume or aformat filters) before
+// they're converted to the output format.
+type AudioFilterGraph struct {
+	description string
+	graph       *filterGraph
+	srcArgs     string
+}
+
+// Description returns the description
+// of the filters of the graph.
+func (filter *AudioFilterGraph) Description() string {
+	return filter.description
+}
+
+// ApplyAudioFilter applies the filter graph defined
+// by the description (e.g., "atempo=1.5,volume=0.8")
+// to the decoded frames of the stream before they're
+// converted to the output format.
+//
+// The filters may change the number of the samples
+// (e.g., atempo), so an audio frame contains all the
+// samples produced for a packet, and it has no samples
+// if the filters need more data to produce them. The
+// timestamps of the frames come from the filters,
+// so they follow the changed tempo continuously.
+func (audio *AudioStream) ApplyAudioFilter(description string) error {
+	if description == "" {
+		return fmt.Errorf("the filter description is empty")
+	}
+
+	filter := &AudioFilterGraph{
+		description: description,
+	}
+
+	// Check the description with the parameters
+	// of the stream if they're known, so the
+	// mistakes are reported right away.
+	if audio.codecParams.sample_rate > 0 && audio.codecParams.format >= 0 &&
+		audio.codecParams.ch_layout.nb_channels > 0 {
+		srcArgs := audioBufferArgs(audio.codecParams.sample_rate,
+			C.enum_AVSampleFormat(audio.codecParams.format),
+			&audio.codecParams.ch_layout, audio.inner.time_base)
+		err := filter.configure(srcArgs)
+
+		if err != nil {
+			filter.reset()
+			return err
+		}
+	}
+
+	audio.RemoveAudioFilter()
+	audio.audioFilter = filter
+
+	return nil
+}
+
+// AudioFilter returns the filter graph applied
+// to the decoded frames of the stream or nil
+// if there's none.
+func (audio *AudioStream) AudioFilter() *AudioFilterGraph {
+	return audio.audioFilter
+}
+
+// RemoveAudioFilter removes the filter
+// graph applied to the decoded frames
+// of the stream.
+func (audio *AudioStream) RemoveAudioFilter() {
+	if audio.audioFilter != nil {
+		audio.audioFilter.reset()
+		audio.audioFilter = nil
+	}
+}
+
+// audioBufferArgs returns the arguments of the
+// buffer source filter for the audio frames.
+func audioBufferArgs(rate C.int, format C.enum_AVSampleFormat, layout *C.AVChannelLayout, timeBase C.AVRational) string {
+	var buf [256]C.char
+	layoutName := ""
+
+	if C.av_channel_layout_describe(layout, &buf[0], C.size_t(len(buf))) >= 0 {
+		layoutName = C.GoString(&buf[0])
+	}
+
+	return fmt.Sprintf(
+		"time_base=%d/%d:sample_rate=%d:sample_fmt=%s:channel_layout=%s",
+		timeBase.num, timeBase.den, rate,
+		C.GoString(C.av_get_sample_fmt_name(format)), layoutName)
+}
+
+// configure (re)creates the filters of the
+// graph for the specified source arguments.
+func (filter *AudioFilterGraph) configure(srcArgs string) error {
+	filter.reset()
+	graph, err := newFilterGraph(filter.description,
+		"abuffer", srcArgs, "abuffersink")
+
+	if err != nil {
+		return err
+	}
+
+	filter.graph = graph
+	filter.srcArgs = srcArgs
+
+	return nil
+}
+
+// readFilteredFrame sends the decoded frame to the
+// filter graph and returns an audio frame with all
+// the samples the filters produced for it.
+func (audio *AudioStream) readFilteredFrame() (*AudioFrame, bool, error) {
+	filter := audio.audioFilter
+	srcArgs := audioBufferArgs(audio.frame.sample_rate,
+		C.enum_AVSampleFormat(audio.frame.format),
+		&audio.frame.ch_layout, audio.inner.time_base)
+
+	// The filter graph is recreated when the
+	// format of the frames changes in the
+	// middle of the stream.
+	if filter.graph == nil || filter.srcArgs != srcArgs {
+		err := filter.configure(srcArgs)
+
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	// The timestamps are unwrapped before the filters
+	// like on the unfiltered path, so the filtered
+	// frames stay continuous across the wraps.
+	audio.frame.pts = C.int64_t(audio.unwrapPTS(int64(audio.frame.pts)))
+	err := filter.graph.push(audio.frame)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var chunks [][]byte
+	framePTS := noPTS
+
+	for {
+		filtered, ok, err := filter.graph.pull()
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !ok {
+			break
+		}
+
+		// The timestamps of the filtered frames
+		// are in the time base of the sink.
+		pts := int64(filtered.pts)
+
+		if pts != noPTS {
+			pts = int64(C.av_rescale_q(filtered.pts,
+				filter.graph.sinkTimeBase(), audio.inner.time_base))
+		}
+
+		samples, samplesPTS, err := audio.convertSamples(filtered, pts)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if samples == nil {
+			continue
+		}
+
+		if chunks == nil {
+			framePTS = samplesPTS
+		}
+
+		chunks = append(chunks, samples)
+	}
+
+	if chunks == nil {
+		return nil, true, nil
+	}
+
+	return audio.newOutputFrame(framePTS,
+		audio.joinSamples(chunks)), true, nil
+}
+
+// joinSamples joins the chunks of the converted
+// samples keeping the planes of the planar
+// formats one after another.
+func (audio *AudioStream) joinSamples(chunks [][]byte) []byte {
+	if len(chunks) == 1 {
+		return chunks[0]
+	}
+
+	size := 0
+
+	for _, chunk := range chunks {
+		size += len(chunk)
+	}
+
+	data := make([]byte, 0, size)
+
+	if !audio.OutputSampleFormat().Planar() {
+		for _, chunk := range chunks {
+			data = append(data, chunk...)
+		}
+
+		return data
+	}
+
+	channels := audio.OutputChannelCount()
+
+	for ch := 0; ch < channels; ch++ {
+		for _, chunk := range chunks {
+			planeSize := len(chunk) / channels
+			data = append(data, chunk[ch*planeSize:(ch+1)*planeSize]...)
+		}
+	}
+
+	return data
+}
+
+// reset frees the filters of the graph
+// dropping the samples buffered by them.
+func (filter *AudioFilterGraph) reset() {
+	if filter.graph != nil {
+		filter.graph.free()
+		filter.graph = nil
+	}
+
+	filter.srcArgs = ""
+}
@@ -8,12 +8,18 @@ package reisen
 // #include <libswscale/swscale.h>
 // #include <inttypes.h>
 import "C"
+import (
+	"encoding/binary"
+	"math"
+)
 
 // AudioFrame is a data frame
 // obtained from an audio stream.
 type AudioFrame struct {
 	baseFrame
-	data []byte
+	data     []byte
+	channels int
+	format   C.enum_AVSampleFormat
 }
 
 // Data returns a raw slice of
@@ -22,6 +28,106 @@ func (frame *AudioFrame) Data() []byte {
 	return frame.data
 }
 
+// Samples returns the samples of the frame
+// deinterleaved into one slice per channel and
+// normalized to floats in the range [-1, 1]
+// regardless of the output sample format.
+func (frame *AudioFrame) Samples() [][]float32 {
+	if frame.channels <= 0 {
+		return nil
+	}
+
+	sampleSize := int(C.av_get_bytes_per_sample(frame.format))
+
+	if sampleSize <= 0 {
+		return nil
+	}
+
+	planar := C.av_sample_fmt_is_planar(frame.format) != 0
+	packedFormat := C.av_get_packed_sample_fmt(frame.format)
+	count := len(frame.data) / (sampleSize * frame.channels)
+	samples := make([][]float32, frame.channels)
+
+	for ch := range samples {
+		samples[ch] = make([]float32, count)
+	}
+
+	for i := 0; i < count; i++ {
+		for ch := 0; ch < frame.channels; ch++ {
+			offset := (i*frame.channels + ch) * sampleSize
+
+			if planar {
+				offset = (ch*count + i) * sampleSize
+			}
+
+			samples[ch][i] = decodeSample(
+				frame.data[offset:offset+sampleSize], packedFormat)
+		}
+	}
+
+	return samples
+}
+
+// Float32Samples returns the samples of the frame
+// in their stored order (interleaved or plane by
+// plane) if the output sample format is 32-bit
+// float, and nil otherwise.
+func (frame *AudioFrame) Float32Samples() []float32 {
+	if frame.format != C.AV_SAMPLE_FMT_FLT &&
+		frame.format != C.AV_SAMPLE_FMT_FLTP {
+		return nil
+	}
+
+	samples := make([]float32, len(frame.data)/4)
+
+	for i := range samples {
+		samples[i] = math.Float32frombits(
+			binary.LittleEndian.Uint32(frame.data[i*4:]))
+	}
+
+	return samples
+}
+
+// SampleFormat returns the sample
+// format of the frame data.
+func (frame *AudioFrame) SampleFormat() SampleFormat {
+	return SampleFormat(frame.format)
+}
+
+// ChannelCount returns the number of
+// channels of the frame data.
+func (frame *AudioFrame) ChannelCount() int {
+	return frame.channels
+}
+
+// decodeSample converts the little-endian sample
+// of the format to a normalized float.
+func decodeSample(data []byte, format C.enum_AVSampleFormat) float32 {
+	switch format {
+	case C.AV_SAMPLE_FMT_U8:
+		return (float32(data[0]) - 128) / 128
+
+	case C.AV_SAMPLE_FMT_S16:
+		return float32(int16(binary.LittleEndian.
+			Uint16(data))) / 32768
+
+	case C.AV_SAMPLE_FMT_S32:
+		return float32(float64(int32(binary.LittleEndian.
+			Uint32(data))) / 2147483648)
+
+	case C.AV_SAMPLE_FMT_FLT:
+		return math.Float32frombits(
+			binary.LittleEndian.Uint32(data))
+
+	case C.AV_SAMPLE_FMT_DBL:
+		return float32(math.Float64frombits(
+			binary.LittleEndian.Uint64(data)))
+
+	default:
+		return 0
+	}
+}
+
 // newAudioFrame returns a newly created audio frame.
 func newAudioFrame(stream Stream, pts int64, indCoded, indDisplay int, data []byte) *AudioFrame {
 	frame := new(AudioFrame)
@@ -0,0 +1,116 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/frame.h>
+import "C"
+
+// SetAutoRotate enables or disables rotating the
+// decoded frames by the rotation of the stream (see
+// Rotation), so the frames of the videos captured
+// in the portrait orientation come out upright.
+// Only the multiples of 90 degrees are applied.
+//
+// The output size of the stream refers
+// to the size of the rotated frames, so
+// Open and OpenDisplayAccurate swap the
+// width and the height of the source for
+// the rotation by 90 or 270 degrees.
+func (video *VideoStream) SetAutoRotate(enabled bool) {
+	video.autoRotate = enabled
+
+	if !enabled {
+		video.freeRotator()
+	}
+}
+
+// AutoRotate returns true if rotating
+// the decoded frames is enabled.
+func (video *VideoStream) AutoRotate() bool {
+	return video.autoRotate
+}
+
+// rotatedSize returns the size of the frames
+// of the specified size after rotating them
+// if the rotation is enabled.
+func (video *VideoStream) rotatedSize(width, height int) (int, int) {
+	if !video.autoRotate {
+		return width, height
+	}
+
+	switch rotation, _ := video.Rotation(); rotation {
+	case 90, 270:
+		return height, width
+
+	default:
+		return width, height
+	}
+}
+
+// rotationFilter returns the description of the
+// filters rotating the frames by the rotation of
+// the stream or "" if no rotation is needed.
+func (video *VideoStream) rotationFilter() string {
+	rotation, _ := video.Rotation()
+
+	switch rotation {
+	case 90:
+		return "transpose=clock"
+
+	case 180:
+		return "hflip,vflip"
+
+	case 270:
+		return "transpose=cclock"
+
+	default:
+		return ""
+	}
+}
+
+// rotateFrame rotates the decoded frame upright.
+// It returns false if the filters need more
+// frames to produce one.
+func (video *VideoStream) rotateFrame(frame *C.AVFrame) (*C.AVFrame, bool, error) {
+	description := video.rotationFilter()
+
+	if description == "" {
+		return frame, true, nil
+	}
+
+	srcArgs := video.frameBufferArgs(frame)
+
+	// The rotator is recreated when the format
+	// of the frames changes in the middle of
+	// the stream.
+	if video.rotateGraph == nil || video.rotateArgs != srcArgs {
+		video.freeRotator()
+		graph, err := newFilterGraph(description,
+			"buffer", srcArgs, "buffersink")
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		video.rotateGraph = graph
+		video.rotateArgs = srcArgs
+	}
+
+	err := video.rotateGraph.push(frame)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return video.rotateGraph.pull()
+}
+
+// freeRotator frees the filter
+// graph of the rotator.
+func (video *VideoStream) freeRotator() {
+	if video.rotateGraph != nil {
+		video.rotateGraph.free()
+		video.rotateGraph = nil
+	}
+
+	video.rotateArgs = ""
+}
@@ -0,0 +1,175 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavutil
+// #include <libavformat/avformat.h>
+// #include <libavformat/avio.h>
+// #include <libavutil/error.h>
+// #include <libavutil/mem.h>
+// #include <stdint.h>
+// #include <string.h>
+//
+// #define MEMORY_AVIO_BUFFER_SIZE 32768
+//
+// typedef struct memory_input {
+//     uint8_t *data;
+//     int64_t size;
+//     int64_t pos;
+// } memory_input;
+//
+// static int memory_read(void *opaque, uint8_t *buf, int buf_size) {
+//     memory_input *input = opaque;
+//     int64_t left = input->size - input->pos;
+//
+//     if (left <= 0)
+//         return AVERROR_EOF;
+//
+//     if (buf_size > left)
+//         buf_size = (int)left;
+//
+//     memcpy(buf, input->data + input->pos, buf_size);
+//     input->pos += buf_size;
+//
+//     return buf_size;
+// }
+//
+// static int64_t memory_seek(void *opaque, int64_t offset, int whence) {
+//     memory_input *input = opaque;
+//     int64_t pos;
+//
+//     switch (whence & ~AVSEEK_FORCE) {
+//     case AVSEEK_SIZE:
+//         return input->size;
+//     case SEEK_SET:
+//         pos = offset;
+//         break;
+//     case SEEK_CUR:
+//         pos = input->pos + offset;
+//         break;
+//     case SEEK_END:
+//         pos = input->size + offset;
+//         break;
+//     default:
+//         return AVERROR(EINVAL);
+//     }
+//
+//     if (pos < 0 || pos > input->size)
+//         return AVERROR(EINVAL);
+//
+//     input->pos = pos;
+//
+//     return pos;
+// }
+//
+// static AVIOContext *new_memory_avio(const uint8_t *data, int64_t size) {
+//     memory_input *input = av_mallocz(sizeof(memory_input));
+//     uint8_t *buffer;
+//     AVIOContext *pb;
+//
+//     if (input == NULL)
+//         return NULL;
+//
+//     input->data = av_malloc(size > 0 ? size : 1);
+//     buffer = av_malloc(MEMORY_AVIO_BUFFER_SIZE);
+//
+//     if (input->data == NULL || buffer == NULL) {
+//         av_free(input->data);
+//         av_free(buffer);
+//         av_free(input);
+//         return NULL;
+//     }
+//
+//     memcpy(input->data, data, size);
+//     input->size = size;
+//
+//     pb = avio_alloc_context(buffer, MEMORY_AVIO_BUFFER_SIZE,
+//         0, input, memory_read, NULL, memory_seek);
+//
+//     if (pb == NULL) {
+//         av_free(input->data);
+//         av_free(buffer);
+//         av_free(input);
+//         return NULL;
+//     }
+//
+//     return pb;
+// }
+//
+// static void free_memory_avio(AVIOContext **pb) {
+//     memory_input *input;
+//
+//     if (*pb == NULL)
+//         return;
+//
+//     input = (*pb)->opaque;
+//
+//     if (input != NULL) {
+//         av_free(input->data);
+//         av_free(input);
+//     }
+//
+//     av_freep(&(*pb)->buffer);
+//     avio_context_free(pb);
+// }
+//
+// static uint8_t *memory_avio_data(AVIOContext *pb, int64_t *size) {
+//     memory_input *input = pb->opaque;
+//
+//     *size = input->size;
+//
+//     return input->data;
+// }
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// NewMediaFromBytes returns a new media container
+// analyzer for the media file contained in the
+// byte slice opened with the given options (nil
+// means the defaults). The data is copied, so the
+// slice can be modified afterwards.
+func NewMediaFromBytes(data []byte, opts *Options) (*Media, error) {
+	var ptr *C.uint8_t
+
+	if len(data) > 0 {
+		ptr = (*C.uint8_t)(unsafe.Pointer(&data[0]))
+	}
+
+	pb := C.new_memory_avio(ptr, C.int64_t(len(data)))
+
+	if pb == nil {
+		return nil, fmt.Errorf(
+			"couldn't create a memory I/O context")
+	}
+
+	interrupt := newInterruptState()
+	media, err := openMediaIO("", pb, func() {
+		C.free_memory_avio(&pb)
+	}, interrupt, opts, nil)
+
+	if err != nil {
+		freeInterruptState(interrupt)
+		return nil, err
+	}
+
+	media.memoryIO = pb
+
+	err = media.findStreams()
+
+	if err != nil {
+		media.Close()
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// memoryAVIOData returns a copy of the data
+// read by the memory I/O context.
+func memoryAVIOData(pb *C.AVIOContext) []byte {
+	var size C.int64_t
+	data := C.memory_avio_data(pb, &size)
+
+	return C.GoBytes(unsafe.Pointer(data), C.int(size))
+}
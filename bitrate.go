@@ -0,0 +1,182 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// cbrTolerance is the maximum relative
+	// deviation of the bit rate of the windows
+	// of a constant bit rate stream from
+	// the average one.
+	cbrTolerance = 0.1
+	// cbrWindow is the minimum duration of
+	// the windows of packets the bit rate
+	// is compared over, so the sizes of
+	// the different types of video frames
+	// are averaged.
+	cbrWindow = 500 * time.Millisecond
+)
+
+// MeasuredBitRate returns the actual bit rate of
+// the stream (in bps) computed from the sizes of
+// its packets within the specified time window
+// since the beginning of the media. It's useful
+// when the container doesn't declare the bit rate.
+func (stream *baseStream) MeasuredBitRate(window time.Duration) (int64, error) {
+	tbNum, tbDen := stream.TimeBase()
+
+	if tbNum <= 0 || tbDen <= 0 {
+		return 0, fmt.Errorf(
+			"the time base of the stream is unknown")
+	}
+
+	limit := int64(window.Seconds() *
+		float64(tbDen) / float64(tbNum))
+	var totalSize int64
+	first, last := noPTS, noPTS
+
+	err := stream.media.scanPackets(func(packet *C.AVPacket) bool {
+		if packet.stream_index != stream.inner.index {
+			return true
+		}
+
+		ts := int64(packet.pts)
+
+		if ts == noPTS {
+			ts = int64(packet.dts)
+		}
+
+		if ts == noPTS {
+			return true
+		}
+
+		if first == noPTS {
+			first = ts
+		}
+
+		if ts-first >= limit {
+			return false
+		}
+
+		totalSize += int64(packet.size)
+		end := ts + int64(packet.duration)
+
+		if end > last {
+			last = end
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if first == noPTS || last <= first {
+		return 0, fmt.Errorf(
+			"couldn't measure the duration of the stream packets")
+	}
+
+	seconds := float64(last-first) *
+		float64(tbNum) / float64(tbDen)
+
+	return int64(float64(totalSize*8) / seconds), nil
+}
+
+// IsConstantBitRate reads the specified number of
+// packets of the stream from the beginning of the
+// media without decoding them and returns true if
+// their bit rate is effectively constant (CBR) and
+// false if it's variable (VBR).
+//
+// The bit rate is compared over the windows of
+// the packets lasting at least half a second,
+// or over the individual packets if the sampled
+// ones are too short for it.
+func (stream *baseStream) IsConstantBitRate(sample int) (bool, error) {
+	if sample <= 0 {
+		return false, fmt.Errorf(
+			"the number of packets to sample must be positive")
+	}
+
+	tbNum, tbDen := stream.TimeBase()
+
+	if tbNum <= 0 || tbDen <= 0 {
+		return false, fmt.Errorf(
+			"the time base of the stream is unknown")
+	}
+
+	windowTicks := int64(cbrWindow.Seconds() *
+		float64(tbDen) / float64(tbNum))
+	packets := 0
+	var packetRates, windowRates []float64
+	var windowSize, windowDuration int64
+
+	err := stream.media.scanPackets(func(packet *C.AVPacket) bool {
+		if packet.stream_index != stream.inner.index {
+			return true
+		}
+
+		packets++
+		size := int64(packet.size)
+		duration := int64(packet.duration)
+
+		if duration > 0 {
+			packetRates = append(packetRates,
+				float64(size)/float64(duration))
+			windowSize += size
+			windowDuration += duration
+
+			if windowDuration >= windowTicks {
+				windowRates = append(windowRates,
+					float64(windowSize)/float64(windowDuration))
+				windowSize, windowDuration = 0, 0
+			}
+		}
+
+		return packets < sample
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	rates := windowRates
+
+	if len(rates) < 2 {
+		rates = packetRates
+	}
+
+	if len(rates) < 2 {
+		return false, fmt.Errorf(
+			"not enough packets with a duration to compare the bit rate")
+	}
+
+	var mean float64
+
+	for _, rate := range rates {
+		mean += rate
+	}
+
+	mean /= float64(len(rates))
+
+	if mean <= 0 {
+		return false, fmt.Errorf(
+			"the packets of the stream are empty")
+	}
+
+	for _, rate := range rates {
+		if math.Abs(rate-mean)/mean > cbrTolerance {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
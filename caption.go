@@ -0,0 +1,66 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/frame.h>
+import "C"
+import (
+	"strings"
+	"unsafe"
+)
+
+// ClosedCaptions returns the raw CEA-608/708
+// cc_data triplets carried by the frame (the
+// ATSC A/53 side data) or nil if there are none.
+func (frame *VideoFrame) ClosedCaptions() []byte {
+	return frame.closedCaptions
+}
+
+// ClosedCaptionText returns the basic characters
+// of the CEA-608 captions of the first field
+// carried by the frame. The control codes
+// (e.g., positioning or colors) are ignored.
+func (frame *VideoFrame) ClosedCaptionText() string {
+	var text strings.Builder
+
+	for i := 0; i+2 < len(frame.closedCaptions); i += 3 {
+		header := frame.closedCaptions[i]
+		valid := header&0x04 != 0
+		ccType := header & 0x03
+
+		// Only the valid NTSC field 1 pairs.
+		if !valid || ccType != 0 {
+			continue
+		}
+
+		// Strip the parity bits.
+		first := frame.closedCaptions[i+1] & 0x7f
+		second := frame.closedCaptions[i+2] & 0x7f
+
+		// The pair is a control code.
+		if first >= 0x10 && first < 0x20 {
+			continue
+		}
+
+		for _, b := range []byte{first, second} {
+			if b >= 0x20 && b < 0x7f {
+				text.WriteByte(b)
+			}
+		}
+	}
+
+	return text.String()
+}
+
+// frameClosedCaptions returns a copy of the
+// closed captions side data of the frame.
+func frameClosedCaptions(frame *C.AVFrame) []byte {
+	sideData := C.av_frame_get_side_data(frame,
+		C.AV_FRAME_DATA_A53_CC)
+
+	if sideData == nil || sideData.data == nil || sideData.size == 0 {
+		return nil
+	}
+
+	return C.GoBytes(unsafe.Pointer(sideData.data),
+		C.int(sideData.size))
+}
@@ -0,0 +1,66 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavutil
+// #include <libavformat/avformat.h>
+import "C"
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// Chapter is a chapter marker
+// of the media file.
+type Chapter struct {
+	// ID is the identifier of the chapter.
+	ID int64
+	// Start and End are the time offsets
+	// of the beginning and the end of the
+	// chapter since the start of the media.
+	Start time.Duration
+	End   time.Duration
+	// Title is the title of the chapter
+	// from its metadata, if any.
+	Title string
+}
+
+// Chapters returns the chapters of the media
+// file (e.g., a podcast or an audiobook).
+func (media *Media) Chapters() []Chapter {
+	chapters := []Chapter{}
+	innerChapters := unsafe.Slice(
+		media.ctx.chapters, media.ctx.nb_chapters)
+
+	for _, inner := range innerChapters {
+		start, err := chapterTime(inner, inner.start)
+
+		if err != nil {
+			continue
+		}
+
+		end, err := chapterTime(inner, inner.end)
+
+		if err != nil {
+			continue
+		}
+
+		chapters = append(chapters, Chapter{
+			ID:    int64(inner.id),
+			Start: start,
+			End:   end,
+			Title: dictionaryValue(inner.metadata, "title"),
+		})
+	}
+
+	return chapters
+}
+
+// chapterTime converts the timestamp in
+// the chapter time base to the duration.
+func chapterTime(chapter *C.AVChapter, ts C.int64_t) (time.Duration, error) {
+	factor := float64(chapter.time_base.num) /
+		float64(chapter.time_base.den)
+	tm := float64(ts) * factor
+
+	return time.ParseDuration(fmt.Sprintf("%fs", tm))
+}
@@ -0,0 +1,39 @@
+package reisen
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// ClippingStats holds the counters of the audio
+// samples clipped while converting them to the
+// 16-bit output samples.
+type ClippingStats struct {
+	// ClippedSamples is the number of the output
+	// samples of all the channels equal to the
+	// minimum or the maximum 16-bit value.
+	ClippedSamples int64
+	// TotalSamples is the number of the output
+	// samples of all the channels.
+	TotalSamples int64
+}
+
+// ClippingStats returns the counters of the
+// audio samples clipped during resampling.
+func (audio *AudioStream) ClippingStats() ClippingStats {
+	return audio.clipping
+}
+
+// countClipping updates the clipping
+// counters with the output samples.
+func (audio *AudioStream) countClipping(data []byte) {
+	for i := 0; i+1 < len(data); i += 2 {
+		sample := int16(binary.LittleEndian.Uint16(data[i:]))
+
+		if sample == math.MaxInt16 || sample == math.MinInt16 {
+			audio.clipping.ClippedSamples++
+		}
+	}
+
+	audio.clipping.TotalSamples += int64(len(data) / 2)
+}
@@ -0,0 +1,66 @@
+package reisen
+
+// #cgo pkg-config: libavcodec
+// #include <libavcodec/avcodec.h>
+import "C"
+import "unsafe"
+
+// CodecParameters is a copy of the codec
+// parameters of a media stream.
+type CodecParameters struct {
+	// CodecType is the type of
+	// the stream media data.
+	CodecType StreamType
+	// CodecID is the libAV
+	// identifier of the codec.
+	CodecID int
+	// CodecTag is the container-specific
+	// tag (FourCC) of the codec.
+	CodecTag uint32
+	// Format is the pixel format of the video
+	// or the sample format of the audio.
+	Format int
+	// BitRate is the bit rate
+	// of the stream (in bps).
+	BitRate int64
+	// Profile is the codec profile.
+	Profile int
+	// Level is the codec level.
+	Level int
+	// Width is the width of the video frames.
+	Width int
+	// Height is the height of the video frames.
+	Height int
+	// SampleRate is the sample rate of the audio.
+	SampleRate int
+	// Channels is the number of audio channels.
+	Channels int
+	// Extradata is the codec private data.
+	Extradata []byte
+}
+
+// CodecParameters returns a copy of
+// the codec parameters of the stream.
+func (stream *baseStream) CodecParameters() *CodecParameters {
+	params := stream.codecParams
+	codecParams := &CodecParameters{
+		CodecType:  StreamType(params.codec_type),
+		CodecID:    int(params.codec_id),
+		CodecTag:   uint32(params.codec_tag),
+		Format:     int(params.format),
+		BitRate:    int64(params.bit_rate),
+		Profile:    int(params.profile),
+		Level:      int(params.level),
+		Width:      int(params.width),
+		Height:     int(params.height),
+		SampleRate: int(params.sample_rate),
+		Channels:   int(params.channels),
+	}
+
+	if params.extradata != nil && params.extradata_size > 0 {
+		codecParams.Extradata = C.GoBytes(unsafe.Pointer(
+			params.extradata), params.extradata_size)
+	}
+
+	return codecParams
+}
@@ -0,0 +1,120 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import "unsafe"
+
+// coverArtStream returns the inner stream
+// holding the attached picture of the media
+// (e.g., the album cover) or nil if there's
+// no such a stream.
+func (media *Media) coverArtStream() *C.AVStream {
+	innerStreams := unsafe.Slice(
+		media.ctx.streams, media.ctx.nb_streams)
+
+	for _, innerStream := range innerStreams {
+		if innerStream.disposition&C.AV_DISPOSITION_ATTACHED_PIC != 0 {
+			return innerStream
+		}
+	}
+
+	return nil
+}
+
+// CoverArtInfo returns the dimensions and the
+// MIME type of the cover art attached to the media
+// without decoding the picture. The MIME type is
+// "" if it's unknown for the picture codec.
+//
+// ok is false if the media has no cover art.
+func (media *Media) CoverArtInfo() (width, height int, mime string, ok bool) {
+	innerStream := media.coverArtStream()
+
+	if innerStream == nil {
+		return 0, 0, "", false
+	}
+
+	codecParams := innerStream.codecpar
+
+	return int(codecParams.width), int(codecParams.height),
+		codecMIMEType(codecParams.codec_id), true
+}
+
+// AttachedPicture is a picture attached to the
+// media (e.g., the album cover of an MP3 or a FLAC
+// file) in its original encoded form.
+type AttachedPicture struct {
+	// StreamIndex is the index of
+	// the stream holding the picture.
+	StreamIndex int
+	// Data contains the encoded
+	// picture (e.g., JPEG or PNG).
+	Data []byte
+	// MIMEType is the MIME type of the
+	// picture or "" if it's unknown.
+	MIMEType string
+	// CodecName is the name of the
+	// picture codec (e.g., "mjpeg").
+	CodecName string
+	// Width and Height are the
+	// dimensions of the picture.
+	Width  int
+	Height int
+}
+
+// AttachedPictures returns all the pictures
+// attached to the media without decoding them.
+func (media *Media) AttachedPictures() []AttachedPicture {
+	media.ctxMu.Lock()
+	defer media.ctxMu.Unlock()
+
+	pictures := []AttachedPicture{}
+	innerStreams := unsafe.Slice(
+		media.ctx.streams, media.ctx.nb_streams)
+
+	for _, innerStream := range innerStreams {
+		if innerStream.disposition&C.AV_DISPOSITION_ATTACHED_PIC == 0 ||
+			innerStream.attached_pic.data == nil {
+			continue
+		}
+
+		codecParams := innerStream.codecpar
+		picture := AttachedPicture{
+			StreamIndex: int(innerStream.index),
+			Data: C.GoBytes(unsafe.Pointer(innerStream.attached_pic.data),
+				innerStream.attached_pic.size),
+			MIMEType: codecMIMEType(codecParams.codec_id),
+			Width:    int(codecParams.width),
+			Height:   int(codecParams.height),
+		}
+
+		if name := C.avcodec_get_name(codecParams.codec_id); name != nil {
+			picture.CodecName = C.GoString(name)
+		}
+
+		pictures = append(pictures, picture)
+	}
+
+	return pictures
+}
+
+// codecMIMEType returns the MIME type of the
+// codec or "" if it's unknown.
+func codecMIMEType(codecID C.enum_AVCodecID) string {
+	desc := C.avcodec_descriptor_get(codecID)
+
+	if desc != nil && desc.mime_types != nil && *desc.mime_types != nil {
+		return C.GoString(*desc.mime_types)
+	}
+
+	return ""
+}
+
+// IsCoverArt returns true if the video stream
+// is the picture attached to the media (e.g.,
+// the album cover) containing a single frame.
+func (video *VideoStream) IsCoverArt() bool {
+	return video.inner.disposition&C.AV_DISPOSITION_ATTACHED_PIC != 0
+}
@@ -0,0 +1,92 @@
+package reisen
+
+import "fmt"
+
+// decodeAhead holds the state of the goroutine
+// decoding the frames of a video stream in advance.
+type decodeAhead struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartDecodeAhead starts a goroutine decoding the
+// frames of the video stream into a channel holding up
+// to the specified number of frames. The goroutine
+// waits while the channel is full, and it stops upon
+// reaching the end of the stream, on an error, which
+// is sent to the error channel, or when the stream
+// is closed. The frame channel is closed afterwards.
+//
+// The goroutine reads the packets of the media, so
+// the media mustn't be read or rewound while it's
+// running. The packets of the other streams are
+// dropped.
+func (video *VideoStream) StartDecodeAhead(depth int) (<-chan *VideoFrame, <-chan error) {
+	errs := make(chan error, 1)
+
+	if video.decodeAhead != nil {
+		errs <- fmt.Errorf("decoding ahead is already started")
+		close(errs)
+
+		return nil, errs
+	}
+
+	if depth < 0 {
+		depth = 0
+	}
+
+	frames := make(chan *VideoFrame, depth)
+	ahead := &decodeAhead{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	video.decodeAhead = ahead
+
+	go func() {
+		defer close(ahead.done)
+		defer close(errs)
+		defer close(frames)
+
+		for {
+			select {
+			case <-ahead.stop:
+				return
+
+			default:
+			}
+
+			frame, ok, err := video.ReadNextVideoFrame()
+
+			if err != nil && !isEndOfFile(err) {
+				errs <- err
+				return
+			}
+
+			if !ok {
+				return
+			}
+
+			select {
+			case frames <- frame:
+			case <-ahead.stop:
+				return
+			}
+		}
+	}()
+
+	return frames, errs
+}
+
+// StopDecodeAhead stops the goroutine decoding
+// the frames of the stream in advance and waits
+// for it to finish.
+func (video *VideoStream) StopDecodeAhead() {
+	if video.decodeAhead == nil {
+		return
+	}
+
+	close(video.decodeAhead.stop)
+	<-video.decodeAhead.done
+	video.decodeAhead = nil
+}
@@ -0,0 +1,50 @@
+package reisen
+
+import "fmt"
+
+// checkLastPacket makes sure the packet last read
+// by Media.ReadPacket belongs to the stream.
+func (stream *baseStream) checkLastPacket() error {
+	packet := stream.media.packet
+
+	if packet == nil || packet.size <= 0 && packet.data == nil {
+		return fmt.Errorf(
+			"no packet has been read")
+	}
+
+	if packet.stream_index != stream.inner.index {
+		return fmt.Errorf(
+			"the last packet belongs to the stream %d, not %d",
+			packet.stream_index, stream.inner.index)
+	}
+
+	return nil
+}
+
+// DecodeLastPacket decodes the packet last read by
+// Media.ReadPacket into a video frame. The packet can
+// be inspected first and skipped without decoding
+// it, e.g., to drop the non-key frames.
+func (video *VideoStream) DecodeLastPacket() (*VideoFrame, bool, error) {
+	err := video.checkLastPacket()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return video.ReadVideoFrame()
+}
+
+// DecodeLastPacket decodes the packet last read by
+// Media.ReadPacket into an audio frame. The packet
+// can be inspected first and skipped without
+// decoding it.
+func (audio *AudioStream) DecodeLastPacket() (*AudioFrame, bool, error) {
+	err := audio.checkLastPacket()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return audio.ReadAudioFrame()
+}
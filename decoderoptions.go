@@ -0,0 +1,78 @@
+package reisen
+
+// #cgo pkg-config: libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavutil/dict.h>
+import "C"
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// SetDecoderOption sets the option of the decoder of
+// the stream, either a generic codec option or the
+// private one (e.g., "view_ids" of the MV-HEVC
+// decoder). It must be called before opening
+// the stream for decoding, and opening the stream
+// fails if the decoder doesn't support the option.
+func (stream *baseStream) SetDecoderOption(key, value string) {
+	if stream.decoderOptions == nil {
+		stream.decoderOptions = map[string]string{}
+	}
+
+	stream.decoderOptions[key] = value
+}
+
+// SelectView selects the view (or the layer) of
+// the multi-view video (e.g., the stereo MV-HEVC)
+// to decode by its identifier, 0 being the base
+// view. The decoder of the stream must support
+// the view selection, otherwise opening the
+// stream fails.
+func (video *VideoStream) SelectView(view int) error {
+	if view < 0 {
+		return fmt.Errorf(
+			"invalid view identifier %d", view)
+	}
+
+	video.SetDecoderOption("view_ids", strconv.Itoa(view))
+
+	return nil
+}
+
+// openCodec opens the codec context of the stream
+// with the decoder options set for the stream.
+func (stream *baseStream) openCodec() error {
+	dict, err := newDictionary(stream.decoderOptions)
+
+	if err != nil {
+		return err
+	}
+
+	defer C.av_dict_free(&dict)
+
+	status := C.avcodec_open2(stream.codecCtx, stream.codec, &dict)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't open the codec context")
+	}
+
+	// The options not consumed by
+	// the decoder are left intact.
+	unused := []string{}
+
+	for key := range dictionaryEntries(dict) {
+		unused = append(unused, key)
+	}
+
+	if len(unused) > 0 {
+		sort.Strings(unused)
+
+		return fmt.Errorf(
+			"the decoder doesn't support the options %v", unused)
+	}
+
+	return nil
+}
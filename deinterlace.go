@@ -0,0 +1,127 @@
+package reisen
+
+// #cgo pkg-config: libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavutil/frame.h>
+import "C"
+
+// deinterlacerDescription is the filter graph of
+// the deinterlacer processing only the frames
+// flagged as interlaced.
+const deinterlacerDescription = "yadif=mode=send_frame:parity=auto:deint=interlaced"
+
+// FieldOrder is the order of the
+// fields of interlaced video frames.
+type FieldOrder int
+
+const (
+	FieldOrderUnknown     FieldOrder = C.AV_FIELD_UNKNOWN
+	FieldOrderProgressive FieldOrder = C.AV_FIELD_PROGRESSIVE
+	// FieldOrderTopFirst denotes the top field
+	// coded first and displayed first.
+	FieldOrderTopFirst FieldOrder = C.AV_FIELD_TT
+	// FieldOrderBottomFirst denotes the bottom
+	// field coded first and displayed first.
+	FieldOrderBottomFirst FieldOrder = C.AV_FIELD_BB
+	// FieldOrderTopCodedBottomFirst denotes the top
+	// field coded first and displayed second.
+	FieldOrderTopCodedBottomFirst FieldOrder = C.AV_FIELD_TB
+	// FieldOrderBottomCodedTopFirst denotes the bottom
+	// field coded first and displayed second.
+	FieldOrderBottomCodedTopFirst FieldOrder = C.AV_FIELD_BT
+)
+
+// String returns the name of the field order.
+func (order FieldOrder) String() string {
+	switch order {
+	case FieldOrderProgressive:
+		return "progressive"
+
+	case FieldOrderTopFirst:
+		return "top first"
+
+	case FieldOrderBottomFirst:
+		return "bottom first"
+
+	case FieldOrderTopCodedBottomFirst:
+		return "top coded, bottom first"
+
+	case FieldOrderBottomCodedTopFirst:
+		return "bottom coded, top first"
+
+	default:
+		return "unknown"
+	}
+}
+
+// FieldOrder returns the field order of the
+// video stream declared by the media container.
+func (video *VideoStream) FieldOrder() FieldOrder {
+	return FieldOrder(video.codecParams.field_order)
+}
+
+// SetDeinterlace enables or disables deinterlacing
+// of the decoded frames. The field parity of the
+// deinterlacer follows the field order flags of
+// each frame, so both top-field-first and
+// bottom-field-first sources (including the mixed
+// ones) are handled, and progressive frames are
+// left intact.
+func (video *VideoStream) SetDeinterlace(enabled bool) {
+	video.deinterlace = enabled
+
+	if !enabled {
+		video.freeDeinterlacer()
+	}
+}
+
+// Deinterlace returns true if deinterlacing
+// of the decoded frames is enabled.
+func (video *VideoStream) Deinterlace() bool {
+	return video.deinterlace
+}
+
+// deinterlaceFrame sends the decoded frame to the
+// deinterlacer and returns the deinterlaced frame.
+// It returns false if the deinterlacer needs more
+// frames to produce one.
+func (video *VideoStream) deinterlaceFrame(frame *C.AVFrame) (*C.AVFrame, bool, error) {
+	srcArgs := video.frameBufferArgs(frame)
+
+	// The deinterlacer is recreated only when the
+	// format of the frames changes in the middle of
+	// the stream. The automatic parity of yadif
+	// follows the field order of every frame, so
+	// its lookahead isn't dropped when it changes.
+	if video.deintGraph == nil || video.deintArgs != srcArgs {
+		video.freeDeinterlacer()
+		graph, err := newFilterGraph(deinterlacerDescription,
+			"buffer", srcArgs, "buffersink")
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		video.deintGraph = graph
+		video.deintArgs = srcArgs
+	}
+
+	err := video.deintGraph.push(frame)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return video.deintGraph.pull()
+}
+
+// freeDeinterlacer frees the
+// filter graph of the deinterlacer.
+func (video *VideoStream) freeDeinterlacer() {
+	if video.deintGraph != nil {
+		video.deintGraph.free()
+		video.deintGraph = nil
+	}
+
+	video.deintArgs = ""
+}
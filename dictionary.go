@@ -0,0 +1,84 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/dict.h>
+// #include <stdlib.h>
+import "C"
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// newDictionary creates a new libAV
+// dictionary holding the specified
+// key-value pairs.
+//
+// The dictionary must be freed with
+// C.av_dict_free afterwards.
+func newDictionary(values map[string]string) (*C.AVDictionary, error) {
+	var dict *C.AVDictionary
+
+	for key, value := range values {
+		cKey := C.CString(key)
+		cValue := C.CString(value)
+		status := C.av_dict_set(&dict, cKey, cValue, 0)
+
+		C.free(unsafe.Pointer(cKey))
+		C.free(unsafe.Pointer(cValue))
+
+		if status < 0 {
+			C.av_dict_free(&dict)
+
+			return nil, newAVError(status, fmt.Sprintf(
+				"couldn't set the dictionary entry %s", key))
+		}
+	}
+
+	return dict, nil
+}
+
+// dictionaryValue returns the value of the
+// entry of the libAV dictionary or "" if
+// there's no such an entry.
+func dictionaryValue(dict *C.AVDictionary, key string) string {
+	cKey := C.CString(key)
+	defer C.free(unsafe.Pointer(cKey))
+
+	entry := C.av_dict_get(dict, cKey, nil, 0)
+
+	if entry == nil || entry.value == nil {
+		return ""
+	}
+
+	return C.GoString(entry.value)
+}
+
+// dictionaryEntries returns all the entries
+// of the libAV dictionary with the keys
+// converted to the lower case.
+func dictionaryEntries(dict *C.AVDictionary) map[string]string {
+	entries := map[string]string{}
+	empty := C.CString("")
+	defer C.free(unsafe.Pointer(empty))
+
+	var entry *C.AVDictionaryEntry
+
+	for {
+		entry = C.av_dict_get(dict, empty,
+			entry, C.AV_DICT_IGNORE_SUFFIX)
+
+		if entry == nil {
+			break
+		}
+
+		if entry.key == nil || entry.value == nil {
+			continue
+		}
+
+		key := strings.ToLower(C.GoString(entry.key))
+		entries[key] = C.GoString(entry.value)
+	}
+
+	return entries
+}
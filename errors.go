@@ -1,5 +1,37 @@
 package reisen
 
+// #cgo pkg-config: libavutil
+// #include <errno.h>
+// #include <libavutil/error.h>
+import "C"
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrStreamNotFound is returned when the
+	// media has no stream of the requested type.
+	ErrStreamNotFound = errors.New("stream not found")
+	// ErrStreamNotOpened is returned when the
+	// stream must be opened for the operation.
+	ErrStreamNotOpened = errors.New("the stream is not opened")
+	// ErrNoFilter is returned when the
+	// stream has no filter applied.
+	ErrNoFilter = errors.New("no filter applied")
+	// ErrPaused is returned by ReadPacket
+	// while the media is paused.
+	ErrPaused = errors.New("the media is paused")
+	// ErrNoThreads is returned when a decoder is
+	// opened while all the threads of the budget
+	// set with SetMaxTotalThreads are in use.
+	ErrNoThreads = errors.New("no decoding threads left")
+	// ErrDecodeLimit is returned when a decoding
+	// limit set in the options is exceeded.
+	ErrDecodeLimit = errors.New("the decoding limit is exceeded")
+)
+
 type ErrorType int
 
 const (
@@ -15,3 +47,94 @@ const (
 	// reaching the end of the media file.
 	ErrorEndOfFile ErrorType = -541478725
 )
+
+// AVError is an error returned by a libAV
+// function with its numeric code.
+//
+// It can be inspected with errors.As
+// to tell the failures apart by the code,
+// and errors.Is(err, io.EOF) holds for the
+// end of file.
+type AVError struct {
+	code    ErrorType
+	message string
+}
+
+// newAVError returns a new error for the
+// libAV code with the message describing
+// the failed operation.
+func newAVError(code C.int, message string) error {
+	return &AVError{
+		code:    ErrorType(code),
+		message: message,
+	}
+}
+
+// endOfFile returns the error to report
+// reaching the end of the media with.
+func (media *Media) endOfFile() error {
+	return newAVError(C.int(ErrorEndOfFile),
+		"reached the end of the media")
+}
+
+// isEndOfFile tells whether the error
+// reports reaching the end of the media.
+func isEndOfFile(err error) bool {
+	return errors.Is(err, io.EOF)
+}
+
+// isTransientError tells whether the error is
+// caused by a failure of the input which may
+// succeed when retried (e.g., a network timeout
+// or an HTTP server error).
+func isTransientError(err error) bool {
+	var avErr *AVError
+
+	if !errors.As(err, &avErr) {
+		return false
+	}
+
+	switch avErr.code {
+	case ErrorAgain,
+		ErrorType(-C.ETIMEDOUT),
+		ErrorType(-C.ECONNREFUSED),
+		ErrorType(-C.ECONNRESET),
+		ErrorType(-C.EIO),
+		ErrorType(C.AVERROR_HTTP_SERVER_ERROR):
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Error returns the text of the error
+// prefixed with the libAV code.
+func (err *AVError) Error() string {
+	return fmt.Sprintf("%d: %s", err.code, err.message)
+}
+
+// Code returns the libAV code of the error
+// (e.g., ErrorEndOfFile or ErrorAgain).
+func (err *AVError) Code() ErrorType {
+	return err.code
+}
+
+// Description returns the description
+// of the libAV code of the error.
+func (err *AVError) Description() string {
+	buf := make([]C.char, C.AV_ERROR_MAX_STRING_SIZE)
+	C.av_strerror(C.int(err.code), &buf[0], C.size_t(len(buf)))
+
+	return C.GoString(&buf[0])
+}
+
+// Unwrap returns io.EOF for the
+// end of file and nil otherwise.
+func (err *AVError) Unwrap() error {
+	if err.code == ErrorEndOfFile {
+		return io.EOF
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/zergon321/reisen"
 )
@@ -54,10 +56,15 @@ func main() {
 		// or an audio frame.
 		var pkt *reisen.Packet
 		pkt, gotPacket, err = media.ReadPacket()
-		handleError(err)
 
 		// Check if the media file
 		// is depleted.
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		handleError(err)
+
 		if !gotPacket {
 			break
 		}
@@ -74,10 +81,15 @@ func main() {
 			}
 
 			videoFrame, gotFrame, err := s.ReadVideoFrame()
-			handleError(err)
 
 			// If the media file is
 			// depleted.
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			handleError(err)
+
 			if !gotFrame {
 				break
 			}
@@ -107,6 +119,11 @@ func main() {
 			}
 
 			audioFrame, gotFrame, err := s.ReadAudioFrame()
+
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
 			handleError(err)
 
 			if !gotFrame {
@@ -3,8 +3,10 @@ package main
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"image"
+	"io"
 	"time"
 
 	"github.com/faiface/beep"
@@ -70,7 +72,8 @@ func readVideoAndAudio(media *reisen.Media) (<-chan *image.RGBA, <-chan [2]float
 		for {
 			packet, gotPacket, err := media.ReadPacket()
 
-			if err != nil {
+			// The end of the media is not an error.
+			if err != nil && !errors.Is(err, io.EOF) {
 				go func(err error) {
 					errs <- err
 				}(err)
@@ -88,7 +91,7 @@ func readVideoAndAudio(media *reisen.Media) (<-chan *image.RGBA, <-chan [2]float
 				s := media.Streams()[packet.StreamIndex()].(*reisen.VideoStream)
 				videoFrame, gotFrame, err := s.ReadVideoFrame()
 
-				if err != nil {
+				if err != nil && !errors.Is(err, io.EOF) {
 					go func(err error) {
 						errs <- err
 					}(err)
@@ -108,7 +111,7 @@ func readVideoAndAudio(media *reisen.Media) (<-chan *image.RGBA, <-chan [2]float
 				s := media.Streams()[packet.StreamIndex()].(*reisen.AudioStream)
 				audioFrame, gotFrame, err := s.ReadAudioFrame()
 
-				if err != nil {
+				if err != nil && !errors.Is(err, io.EOF) {
 					go func(err error) {
 						errs <- err
 					}(err)
@@ -0,0 +1,247 @@
+package reisen
+
+// #cgo pkg-config: libavfilter libavutil
+// #include <libavfilter/avfilter.h>
+// #include <libavfilter/buffersrc.h>
+// #include <libavfilter/buffersink.h>
+// #include <libavutil/frame.h>
+// #include <libavutil/mem.h>
+// #include <stdlib.h>
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// filterGraph is a libavfilter graph
+// processing decoded frames: the frames
+// are pushed into its buffer source and
+// pulled from its buffer sink.
+type filterGraph struct {
+	graph       *C.AVFilterGraph
+	src         *C.AVFilterContext
+	sink        *C.AVFilterContext
+	frame       *C.AVFrame
+	description string
+	// The hardware frames context of the
+	// source frames if they're on a device.
+	hwFramesCtx *C.AVBufferRef
+}
+
+// newFilterGraph creates a new filter graph with the
+// specified source and sink filters (e.g., "buffer"
+// and "buffersink" for video frames), the arguments
+// of the source filter and the description of the
+// filters linked between them.
+func newFilterGraph(description, srcName, srcArgs, sinkName string) (*filterGraph, error) {
+	graph := &filterGraph{
+		graph:       C.avfilter_graph_alloc(),
+		description: description,
+	}
+
+	if graph.graph == nil {
+		return nil, fmt.Errorf(
+			"couldn't allocate a filter graph")
+	}
+
+	err := graph.init(srcName, srcArgs, sinkName)
+
+	if err != nil {
+		graph.free()
+		return nil, err
+	}
+
+	return graph, nil
+}
+
+// newHWFilterGraph creates a new video filter graph
+// processing the frames stored on the hardware device
+// of the specified frames context.
+func newHWFilterGraph(description, srcArgs string, hwFramesCtx *C.AVBufferRef) (*filterGraph, error) {
+	graph := &filterGraph{
+		graph:       C.avfilter_graph_alloc(),
+		description: description,
+		hwFramesCtx: hwFramesCtx,
+	}
+
+	if graph.graph == nil {
+		return nil, fmt.Errorf(
+			"couldn't allocate a filter graph")
+	}
+
+	err := graph.init("buffer", srcArgs, "buffersink")
+
+	if err != nil {
+		graph.free()
+		return nil, err
+	}
+
+	return graph, nil
+}
+
+// init creates the filters of the
+// graph and links them together.
+func (graph *filterGraph) init(srcName, srcArgs, sinkName string) error {
+	cSrcName := C.CString(srcName)
+	defer C.free(unsafe.Pointer(cSrcName))
+	cSinkName := C.CString(sinkName)
+	defer C.free(unsafe.Pointer(cSinkName))
+	cSrcArgs := C.CString(srcArgs)
+	defer C.free(unsafe.Pointer(cSrcArgs))
+	cIn := C.CString("in")
+	defer C.free(unsafe.Pointer(cIn))
+	cOut := C.CString("out")
+	defer C.free(unsafe.Pointer(cOut))
+	cDescription := C.CString(graph.description)
+	defer C.free(unsafe.Pointer(cDescription))
+
+	srcFilter := C.avfilter_get_by_name(cSrcName)
+	sinkFilter := C.avfilter_get_by_name(cSinkName)
+
+	if srcFilter == nil || sinkFilter == nil {
+		return fmt.Errorf(
+			"couldn't find the buffer filters")
+	}
+
+	status := C.avfilter_graph_create_filter(&graph.src,
+		srcFilter, cIn, cSrcArgs, nil, graph.graph)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't create the buffer source")
+	}
+
+	if graph.hwFramesCtx != nil {
+		params := C.av_buffersrc_parameters_alloc()
+
+		if params == nil {
+			return fmt.Errorf(
+				"couldn't allocate the buffer source parameters")
+		}
+
+		params.hw_frames_ctx = graph.hwFramesCtx
+		status = C.av_buffersrc_parameters_set(graph.src, params)
+		C.av_free(unsafe.Pointer(params))
+
+		if status < 0 {
+			return newAVError(status,
+				"couldn't set the buffer source parameters")
+		}
+	}
+
+	status = C.avfilter_graph_create_filter(&graph.sink,
+		sinkFilter, cOut, nil, nil, graph.graph)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't create the buffer sink")
+	}
+
+	outputs := C.avfilter_inout_alloc()
+	inputs := C.avfilter_inout_alloc()
+	defer C.avfilter_inout_free(&outputs)
+	defer C.avfilter_inout_free(&inputs)
+
+	if outputs == nil || inputs == nil {
+		return fmt.Errorf(
+			"couldn't allocate the filter graph endpoints")
+	}
+
+	outputs.name = C.av_strdup(cIn)
+	outputs.filter_ctx = graph.src
+	outputs.pad_idx = 0
+	outputs.next = nil
+
+	inputs.name = C.av_strdup(cOut)
+	inputs.filter_ctx = graph.sink
+	inputs.pad_idx = 0
+	inputs.next = nil
+
+	status = C.avfilter_graph_parse_ptr(graph.graph,
+		cDescription, &inputs, &outputs, nil)
+
+	if status < 0 {
+		return newAVError(status, fmt.Sprintf(
+			"couldn't parse the filter graph %s",
+			graph.description))
+	}
+
+	status = C.avfilter_graph_config(graph.graph, nil)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't configure the filter graph")
+	}
+
+	graph.frame = C.av_frame_alloc()
+
+	if graph.frame == nil {
+		return fmt.Errorf(
+			"couldn't allocate a filtered frame")
+	}
+
+	return nil
+}
+
+// push sends the frame to the filter graph
+// keeping the reference to the frame.
+func (graph *filterGraph) push(frame *C.AVFrame) error {
+	status := C.av_buffersrc_add_frame_flags(graph.src,
+		frame, C.AV_BUFFERSRC_FLAG_KEEP_REF)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't send the frame to the filter graph")
+	}
+
+	return nil
+}
+
+// pull receives the next filtered frame from the
+// filter graph. It returns false if the filter
+// graph needs more frames to produce one.
+func (graph *filterGraph) pull() (*C.AVFrame, bool, error) {
+	C.av_frame_unref(graph.frame)
+	status := C.av_buffersink_get_frame(graph.sink, graph.frame)
+
+	if status < 0 {
+		if status == C.int(ErrorAgain) ||
+			status == C.int(ErrorEndOfFile) {
+			return nil, false, nil
+		}
+
+		return nil, false, newAVError(status,
+			"couldn't receive the frame from the filter graph")
+	}
+
+	return graph.frame, true, nil
+}
+
+// sinkTimeBase returns the time base
+// of the frames of the buffer sink.
+func (graph *filterGraph) sinkTimeBase() C.AVRational {
+	return C.av_buffersink_get_time_base(graph.sink)
+}
+
+// filterExists returns 'true' if libavfilter
+// has the filter with the specified name.
+func filterExists(name string) bool {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	return C.avfilter_get_by_name(cName) != nil
+}
+
+// free frees the memory of the filter graph.
+func (graph *filterGraph) free() {
+	if graph.frame != nil {
+		C.av_frame_free(&graph.frame)
+	}
+
+	if graph.graph != nil {
+		C.avfilter_graph_free(&graph.graph)
+	}
+
+	graph.src = nil
+	graph.sink = nil
+}
@@ -0,0 +1,46 @@
+package reisen
+
+// #cgo pkg-config: libavcodec
+// #include <libavcodec/avcodec.h>
+import "C"
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"unsafe"
+)
+
+// ParametersFingerprint returns the SHA-256 hash of
+// the codec parameters of the stream: the codec, its
+// profile and level, the resolution or the sample
+// rate with the channel count, the sample or pixel
+// format and the codec private data (extradata).
+//
+// The container-specific fields (e.g., the codec
+// tag) are left out, so the same encode remuxed
+// into another container has the same fingerprint.
+func (stream *baseStream) ParametersFingerprint() []byte {
+	params := stream.codecParams
+	hash := sha256.New()
+	fields := []int64{
+		int64(params.codec_type),
+		int64(params.codec_id),
+		int64(params.profile),
+		int64(params.level),
+		int64(params.format),
+		int64(params.width),
+		int64(params.height),
+		int64(params.sample_rate),
+		int64(params.channels),
+		int64(params.bits_per_raw_sample),
+		int64(params.extradata_size),
+	}
+
+	binary.Write(hash, binary.LittleEndian, fields)
+
+	if params.extradata != nil && params.extradata_size > 0 {
+		hash.Write(unsafe.Slice((*byte)(unsafe.Pointer(
+			params.extradata)), params.extradata_size))
+	}
+
+	return hash.Sum(nil)
+}
@@ -0,0 +1,178 @@
+package reisen
+
+import (
+	"container/list"
+	"fmt"
+	"time"
+)
+
+// frameCache is an LRU cache of the
+// decoded video frames keyed by PTS.
+type frameCache struct {
+	size    int
+	order   *list.List
+	entries map[int64]*list.Element
+}
+
+// newFrameCache returns a new frame
+// cache holding up to size frames.
+func newFrameCache(size int) *frameCache {
+	return &frameCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[int64]*list.Element{},
+	}
+}
+
+// put adds the frame to the cache evicting
+// the least recently used frames.
+func (cache *frameCache) put(frame *VideoFrame) {
+	if frame.pts == noPTS {
+		return
+	}
+
+	if elem, ok := cache.entries[frame.pts]; ok {
+		elem.Value = frame
+		cache.order.MoveToFront(elem)
+
+		return
+	}
+
+	cache.entries[frame.pts] = cache.order.PushFront(frame)
+	cache.trim()
+}
+
+// find returns the cached frame displayed at
+// the timestamp, i.e., the latest frame with
+// the PTS not exceeding the timestamp within
+// the frame interval.
+func (cache *frameCache) find(ts, interval int64) *VideoFrame {
+	var found *list.Element
+
+	for pts, elem := range cache.entries {
+		if pts > ts || ts-pts > interval {
+			continue
+		}
+
+		if found == nil || pts > found.Value.(*VideoFrame).pts {
+			found = elem
+		}
+	}
+
+	if found == nil {
+		return nil
+	}
+
+	cache.order.MoveToFront(found)
+
+	return found.Value.(*VideoFrame)
+}
+
+// clear removes all the frames from the cache.
+func (cache *frameCache) clear() {
+	cache.order.Init()
+	cache.entries = map[int64]*list.Element{}
+}
+
+// trim evicts the least recently used frames
+// exceeding the size of the cache.
+func (cache *frameCache) trim() {
+	for cache.order.Len() > cache.size {
+		elem := cache.order.Back()
+		cache.order.Remove(elem)
+		delete(cache.entries, elem.Value.(*VideoFrame).pts)
+	}
+}
+
+// SetFrameCacheSize sets the maximum number of the
+// decoded frames kept in the cache consulted by
+// FrameAt, so revisiting the recently viewed
+// positions doesn't require decoding the frames
+// again. The cache is disabled by default and
+// when the size is not positive.
+func (video *VideoStream) SetFrameCacheSize(n int) {
+	if n <= 0 {
+		video.frameCache = nil
+		return
+	}
+
+	if video.frameCache == nil {
+		video.frameCache = newFrameCache(n)
+		return
+	}
+
+	video.frameCache.size = n
+	video.frameCache.trim()
+}
+
+// FrameAt returns the video frame displayed at
+// the specified time location. The media is rewound
+// to the nearest preceding keyframe and decoded up
+// to the location unless the frame is cached.
+//
+// The packets of the other streams read
+// on the way are discarded.
+func (video *VideoStream) FrameAt(t time.Duration) (*VideoFrame, error) {
+	tbNum, tbDen := video.TimeBase()
+	ts := int64(t.Seconds() * float64(tbDen) / float64(tbNum))
+
+	if video.frameCache != nil {
+		frame := video.frameCache.find(ts, video.frameInterval())
+
+		if frame != nil {
+			return frame, nil
+		}
+	}
+
+	err := video.Rewind(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var last *VideoFrame
+
+	for {
+		frame, ok, err := video.ReadNextVideoFrame()
+
+		if err != nil && !isEndOfFile(err) {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		if frame.pts != noPTS && frame.pts > ts {
+			if last == nil {
+				last = frame
+			}
+
+			break
+		}
+
+		last = frame
+	}
+
+	if last == nil {
+		return nil, fmt.Errorf(
+			"couldn't find a frame at %v", t)
+	}
+
+	return last, nil
+}
+
+// frameInterval returns the duration of a frame
+// of the stream in time base units or 0 if the
+// frame rate is unknown.
+func (video *VideoStream) frameInterval() int64 {
+	tbNum, tbDen := video.TimeBase()
+	frNum, frDen := video.FrameRate()
+
+	if tbNum <= 0 || frNum <= 0 {
+		return 0
+	}
+
+	return int64(tbDen) * int64(frDen) /
+		(int64(tbNum) * int64(frNum))
+}
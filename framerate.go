@@ -0,0 +1,68 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import (
+	"fmt"
+	"sort"
+)
+
+// MeasuredFrameRate returns the actual average frame
+// rate of the stream (in FPS) computed from the
+// presentation timestamps of the specified number of
+// its first frames. Unlike FrameRate, it reflects the
+// real pace of the variable frame rate videos.
+func (video *VideoStream) MeasuredFrameRate(sampleFrames int) (float64, error) {
+	if sampleFrames < 2 {
+		return 0, fmt.Errorf(
+			"at least 2 frames are needed to measure the frame rate")
+	}
+
+	tbNum, tbDen := video.TimeBase()
+
+	if tbNum <= 0 || tbDen <= 0 {
+		return 0, fmt.Errorf(
+			"the time base of the stream is unknown")
+	}
+
+	timestamps := make([]int64, 0, sampleFrames)
+
+	err := video.media.scanPackets(func(packet *C.AVPacket) bool {
+		if packet.stream_index != video.inner.index {
+			return true
+		}
+
+		if int64(packet.pts) != noPTS {
+			timestamps = append(timestamps, int64(packet.pts))
+		}
+
+		return len(timestamps) < sampleFrames
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(timestamps) < 2 {
+		return 0, fmt.Errorf(
+			"couldn't find enough frame timestamps")
+	}
+
+	// The packets are in the decoding order.
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+
+	span := timestamps[len(timestamps)-1] - timestamps[0]
+
+	if span <= 0 {
+		return 0, fmt.Errorf(
+			"couldn't measure the duration of the frames")
+	}
+
+	seconds := float64(span) * float64(tbNum) / float64(tbDen)
+
+	return float64(len(timestamps)-1) / seconds, nil
+}
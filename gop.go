@@ -0,0 +1,107 @@
+package reisen
+
+// #cgo pkg-config: libavcodec
+// #include <libavcodec/avcodec.h>
+import "C"
+
+// GOPStats holds the statistics of the group
+// of pictures (GOP) structure of a video stream.
+type GOPStats struct {
+	// Frames is the total number
+	// of frames of the stream.
+	Frames int
+	// Keyframes is the number of
+	// keyframes of the stream.
+	Keyframes int
+	// BFrames is the estimated number of the
+	// bidirectionally predicted frames, i.e.,
+	// the frames displayed before a frame that
+	// precedes them in the decoding order.
+	BFrames int
+	// GOPs is the number of GOPs.
+	GOPs int
+	// AverageGOPLength is the mean
+	// number of frames in a GOP.
+	AverageGOPLength float64
+	// MinGOPLength is the number of
+	// frames in the shortest GOP.
+	MinGOPLength int
+	// MaxGOPLength is the number of
+	// frames in the longest GOP.
+	MaxGOPLength int
+	// GOPLengths maps the GOP length (the keyframe
+	// interval in frames) to the number of GOPs
+	// of that length.
+	GOPLengths map[int]int
+}
+
+// AnalyzeGOP reads all the packets of the video
+// stream without decoding them and reports the
+// statistics of its GOP structure using the
+// packet flags and timestamps.
+func (video *VideoStream) AnalyzeGOP() (*GOPStats, error) {
+	stats := &GOPStats{
+		GOPLengths: map[int]int{},
+	}
+	gopLength := 0
+	maxPTS := noPTS
+
+	addGOP := func() {
+		if gopLength <= 0 {
+			return
+		}
+
+		stats.GOPs++
+		stats.GOPLengths[gopLength]++
+
+		if stats.MinGOPLength == 0 || gopLength < stats.MinGOPLength {
+			stats.MinGOPLength = gopLength
+		}
+
+		if gopLength > stats.MaxGOPLength {
+			stats.MaxGOPLength = gopLength
+		}
+	}
+
+	err := video.media.scanPackets(func(packet *C.AVPacket) bool {
+		if packet.stream_index != video.inner.index {
+			return true
+		}
+
+		stats.Frames++
+
+		if packet.flags&C.AV_PKT_FLAG_KEY != 0 {
+			stats.Keyframes++
+			addGOP()
+			gopLength = 0
+		}
+
+		gopLength++
+		pts := int64(packet.pts)
+
+		if pts != noPTS {
+			if maxPTS != noPTS && pts < maxPTS {
+				stats.BFrames++
+			}
+
+			if pts > maxPTS {
+				maxPTS = pts
+			}
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	addGOP()
+
+	if stats.GOPs > 0 {
+		stats.AverageGOPLength = float64(stats.Frames) /
+			float64(stats.GOPs)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,230 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/frame.h>
+// #include <libavutil/hdr_dynamic_metadata.h>
+import "C"
+import "unsafe"
+
+// HDR10PlusMetadata is the dynamic HDR10+ metadata
+// (SMPTE ST 2094-40) of a video frame with the
+// parameters of tone mapping the scene. The
+// normalized values are in the range [0, 1].
+type HDR10PlusMetadata struct {
+	// CountryCode is the ITU-T T.35 country code.
+	CountryCode int
+	// ApplicationVersion is the version
+	// of the HDR10+ application.
+	ApplicationVersion int
+	// Windows are the processing windows
+	// of the frame, the first one is the
+	// whole frame.
+	Windows []HDR10PlusWindow
+	// TargetedSystemDisplayMaxLuminance is the
+	// nominal maximum luminance of the targeted
+	// display in cd/m².
+	TargetedSystemDisplayMaxLuminance float64
+	// TargetedSystemDisplayActualPeakLuminance is
+	// the normalized actual peak luminance of the
+	// targeted display by rows and columns or
+	// nil if it's not present.
+	TargetedSystemDisplayActualPeakLuminance [][]float64
+	// MasteringDisplayActualPeakLuminance is
+	// the normalized actual peak luminance of the
+	// mastering display by rows and columns or
+	// nil if it's not present.
+	MasteringDisplayActualPeakLuminance [][]float64
+}
+
+// HDR10PlusWindow is a processing window of
+// the HDR10+ metadata with its color
+// transform parameters.
+type HDR10PlusWindow struct {
+	// UpperLeftX, UpperLeftY, LowerRightX
+	// and LowerRightY are the corners of the
+	// window relative to the frame size.
+	UpperLeftX  float64
+	UpperLeftY  float64
+	LowerRightX float64
+	LowerRightY float64
+	// MaxSCL are the maximums of the red, green
+	// and blue components of the pixels.
+	MaxSCL [3]float64
+	// AverageMaxRGB is the average of the
+	// maximum components of the pixels.
+	AverageMaxRGB float64
+	// DistributionMaxRGB are the percentiles of
+	// the maximum components of the pixels.
+	DistributionMaxRGB []HDR10PlusPercentile
+	// FractionBrightPixels is the fraction of the
+	// pixels brighter than the largest percentile.
+	FractionBrightPixels float64
+	// ToneMapping tells whether the tone mapping
+	// curve of the knee point and the Bézier
+	// curve anchors is present.
+	ToneMapping bool
+	// KneePointX and KneePointY are
+	// the knee point of the tone
+	// mapping curve.
+	KneePointX float64
+	KneePointY float64
+	// BezierCurveAnchors are the anchors of
+	// the Bézier curve of the tone mapping.
+	BezierCurveAnchors []float64
+	// ColorSaturationWeight is the color
+	// saturation gain or 0 if it's not
+	// present.
+	ColorSaturationWeight float64
+}
+
+// HDR10PlusPercentile is a percentile of the
+// distribution of the pixel luminance.
+type HDR10PlusPercentile struct {
+	// Percentage is the percentage
+	// of the pixels (0-100).
+	Percentage int
+	// Value is the normalized luminance.
+	Value float64
+}
+
+// HDR10Plus returns the dynamic HDR10+ metadata
+// of the frame. It returns false if the frame
+// doesn't carry it.
+func (frame *VideoFrame) HDR10Plus() (*HDR10PlusMetadata, bool) {
+	return frame.hdr10Plus, frame.hdr10Plus != nil
+}
+
+// frameHDR10Plus parses the dynamic HDR10+
+// side data of the frame if any.
+func frameHDR10Plus(frame *C.AVFrame) *HDR10PlusMetadata {
+	sideData := C.av_frame_get_side_data(frame,
+		C.AV_FRAME_DATA_DYNAMIC_HDR_PLUS)
+
+	if sideData == nil || sideData.data == nil ||
+		sideData.size < C.size_t(unsafe.Sizeof(C.AVDynamicHDRPlus{})) {
+		return nil
+	}
+
+	hdr := (*C.AVDynamicHDRPlus)(unsafe.Pointer(sideData.data))
+	metadata := &HDR10PlusMetadata{
+		CountryCode:        int(hdr.itu_t_t35_country_code),
+		ApplicationVersion: int(hdr.application_version),
+		TargetedSystemDisplayMaxLuminance: rationalFloat(
+			hdr.targeted_system_display_maximum_luminance),
+	}
+
+	numWindows := int(hdr.num_windows)
+
+	if numWindows > len(hdr.params) {
+		numWindows = len(hdr.params)
+	}
+
+	for i := 0; i < numWindows; i++ {
+		metadata.Windows = append(metadata.Windows,
+			hdr10PlusWindow(&hdr.params[i]))
+	}
+
+	if hdr.targeted_system_display_actual_peak_luminance_flag != 0 {
+		metadata.TargetedSystemDisplayActualPeakLuminance = luminanceMatrix(
+			&hdr.targeted_system_display_actual_peak_luminance,
+			int(hdr.num_rows_targeted_system_display_actual_peak_luminance),
+			int(hdr.num_cols_targeted_system_display_actual_peak_luminance))
+	}
+
+	if hdr.mastering_display_actual_peak_luminance_flag != 0 {
+		metadata.MasteringDisplayActualPeakLuminance = luminanceMatrix(
+			&hdr.mastering_display_actual_peak_luminance,
+			int(hdr.num_rows_mastering_display_actual_peak_luminance),
+			int(hdr.num_cols_mastering_display_actual_peak_luminance))
+	}
+
+	return metadata
+}
+
+// hdr10PlusWindow converts the color
+// transform parameters of the window.
+func hdr10PlusWindow(params *C.AVHDRPlusColorTransformParams) HDR10PlusWindow {
+	window := HDR10PlusWindow{
+		UpperLeftX:           rationalFloat(params.window_upper_left_corner_x),
+		UpperLeftY:           rationalFloat(params.window_upper_left_corner_y),
+		LowerRightX:          rationalFloat(params.window_lower_right_corner_x),
+		LowerRightY:          rationalFloat(params.window_lower_right_corner_y),
+		AverageMaxRGB:        rationalFloat(params.average_maxrgb),
+		FractionBrightPixels: rationalFloat(params.fraction_bright_pixels),
+		ToneMapping:          params.tone_mapping_flag != 0,
+	}
+
+	for i := range window.MaxSCL {
+		window.MaxSCL[i] = rationalFloat(params.maxscl[i])
+	}
+
+	percentiles := int(params.num_distribution_maxrgb_percentiles)
+
+	if percentiles > len(params.distribution_maxrgb) {
+		percentiles = len(params.distribution_maxrgb)
+	}
+
+	for i := 0; i < percentiles; i++ {
+		window.DistributionMaxRGB = append(window.DistributionMaxRGB,
+			HDR10PlusPercentile{
+				Percentage: int(params.distribution_maxrgb[i].percentage),
+				Value:      rationalFloat(params.distribution_maxrgb[i].percentile),
+			})
+	}
+
+	if window.ToneMapping {
+		window.KneePointX = rationalFloat(params.knee_point_x)
+		window.KneePointY = rationalFloat(params.knee_point_y)
+		anchors := int(params.num_bezier_curve_anchors)
+
+		if anchors > len(params.bezier_curve_anchors) {
+			anchors = len(params.bezier_curve_anchors)
+		}
+
+		for i := 0; i < anchors; i++ {
+			window.BezierCurveAnchors = append(window.BezierCurveAnchors,
+				rationalFloat(params.bezier_curve_anchors[i]))
+		}
+	}
+
+	if params.color_saturation_mapping_flag != 0 {
+		window.ColorSaturationWeight = rationalFloat(
+			params.color_saturation_weight)
+	}
+
+	return window
+}
+
+// luminanceMatrix converts the specified rows
+// and columns of the peak luminance matrix.
+func luminanceMatrix(matrix *[25][25]C.AVRational, rows, cols int) [][]float64 {
+	if rows > len(matrix) {
+		rows = len(matrix)
+	}
+
+	if cols > len(matrix[0]) {
+		cols = len(matrix[0])
+	}
+
+	values := make([][]float64, rows)
+
+	for i := range values {
+		values[i] = make([]float64, cols)
+
+		for j := range values[i] {
+			values[i][j] = rationalFloat(matrix[i][j])
+		}
+	}
+
+	return values
+}
+
+// rationalFloat returns the value of the
+// rational number or 0 if it's undefined.
+func rationalFloat(q C.AVRational) float64 {
+	if q.den == 0 {
+		return 0
+	}
+
+	return float64(q.num) / float64(q.den)
+}
@@ -0,0 +1,253 @@
+package reisen
+
+// #cgo pkg-config: libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavutil/hwcontext.h>
+// #include <stdint.h>
+// #include <stdlib.h>
+//
+// static enum AVPixelFormat get_hw_format(AVCodecContext *ctx,
+//     const enum AVPixelFormat *formats) {
+//     enum AVPixelFormat hw_format = (enum AVPixelFormat)(intptr_t)ctx->opaque;
+//     const enum AVPixelFormat *p;
+//
+//     for (p = formats; *p != AV_PIX_FMT_NONE; p++) {
+//         if (*p == hw_format)
+//             return *p;
+//     }
+//
+//     return avcodec_default_get_format(ctx, formats);
+// }
+//
+// static void set_hw_format(AVCodecContext *ctx, enum AVPixelFormat format) {
+//     ctx->opaque = (void *)(intptr_t)format;
+//     ctx->get_format = get_hw_format;
+// }
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// maxProbePackets is the maximum number of packets
+// read to verify that the decoder produces frames.
+const maxProbePackets = 256
+
+// hwDeviceTypeByName returns the hardware
+// device type of the specified name.
+func hwDeviceTypeByName(name string) C.enum_AVHWDeviceType {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	return C.av_hwdevice_find_type_by_name(cName)
+}
+
+// initHWDevice creates the hardware device context
+// for the decoder of the stream. It must be called
+// before the codec context is opened.
+func (stream *baseStream) initHWDevice() error {
+	stream.hwPixFmt = C.AV_PIX_FMT_NONE
+
+	for i := C.int(0); ; i++ {
+		config := C.avcodec_get_hw_config(stream.codec, i)
+
+		if config == nil {
+			return fmt.Errorf(
+				"the codec doesn't support the %s device",
+				C.GoString(C.av_hwdevice_get_type_name(
+					stream.hwDeviceType)))
+		}
+
+		if config.methods&C.AV_CODEC_HW_CONFIG_METHOD_HW_DEVICE_CTX != 0 &&
+			config.device_type == stream.hwDeviceType {
+			stream.hwPixFmt = config.pix_fmt
+			break
+		}
+	}
+
+	status := C.av_hwdevice_ctx_create(&stream.hwDeviceCtx,
+		stream.hwDeviceType, nil, nil, 0)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't create the hardware device context")
+	}
+
+	stream.codecCtx.hw_device_ctx = C.av_buffer_ref(stream.hwDeviceCtx)
+
+	if stream.codecCtx.hw_device_ctx == nil {
+		return fmt.Errorf(
+			"couldn't reference the hardware device context")
+	}
+
+	C.set_hw_format(stream.codecCtx, stream.hwPixFmt)
+
+	stream.hwFrame = C.av_frame_alloc()
+
+	if stream.hwFrame == nil {
+		return fmt.Errorf(
+			"couldn't allocate a new frame")
+	}
+
+	return nil
+}
+
+// transferHWFrame copies the decoded frame from
+// the memory of the hardware device to the system
+// memory if it's been decoded on the hardware.
+func (stream *baseStream) transferHWFrame(frame *C.AVFrame) (*C.AVFrame, error) {
+	if stream.hwFrame == nil ||
+		C.enum_AVPixelFormat(frame.format) != stream.hwPixFmt {
+		return frame, nil
+	}
+
+	C.av_frame_unref(stream.hwFrame)
+	status := C.av_hwframe_transfer_data(stream.hwFrame, frame, 0)
+
+	if status < 0 {
+		return nil, newAVError(status,
+			"couldn't transfer the frame from the hardware device")
+	}
+
+	status = C.av_frame_copy_props(stream.hwFrame, frame)
+
+	if status < 0 {
+		return nil, newAVError(status,
+			"couldn't copy the frame properties")
+	}
+
+	return stream.hwFrame, nil
+}
+
+// freeHWDevice frees the hardware device
+// context of the stream decoder.
+func (stream *baseStream) freeHWDevice() {
+	if stream.hwFrame != nil {
+		C.av_frame_free(&stream.hwFrame)
+	}
+
+	if stream.hwDeviceCtx != nil {
+		C.av_buffer_unref(&stream.hwDeviceCtx)
+	}
+
+	stream.hwPixFmt = C.AV_PIX_FMT_NONE
+}
+
+// ActiveHWDevice returns the name of the hardware
+// device the stream is decoded on or "" if it's
+// decoded in software.
+func (video *VideoStream) ActiveHWDevice() string {
+	if video.hwDeviceCtx == nil {
+		return ""
+	}
+
+	return C.GoString(C.av_hwdevice_get_type_name(
+		video.hwDeviceType))
+}
+
+// OpenDecodeAuto opens the video stream for decoding
+// on the best hardware device available for the
+// platform. Each device is verified by decoding the
+// first frame of the stream, and the stream falls
+// back to the software decoder if no device works.
+// ActiveHWDevice reports the chosen device.
+//
+// The media must be opened for decoding. It's
+// rewound to the beginning after the verification.
+func (video *VideoStream) OpenDecodeAuto(width, height int, alg InterpolationAlgorithm) error {
+	for _, name := range hwDevicePreference {
+		deviceType := hwDeviceTypeByName(name)
+
+		if deviceType == C.AV_HWDEVICE_TYPE_NONE {
+			continue
+		}
+
+		video.hwDeviceType = deviceType
+		err := video.OpenDecode(width, height, alg)
+
+		if err == nil {
+			err = video.probeDecode()
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		// The codec context of the failed device is
+		// freed, so the next one starts from scratch.
+		video.Close()
+		C.avcodec_free_context(&video.codecCtx)
+	}
+
+	video.hwDeviceType = C.AV_HWDEVICE_TYPE_NONE
+
+	return video.OpenDecode(width, height, alg)
+}
+
+// probeDecode verifies that the decoder produces
+// a frame on the hardware device from the first
+// packets of the stream and then rewinds the
+// media to the beginning.
+// The media is rewound and the decoder state is
+// reset whether the verification succeeds or not.
+func (video *VideoStream) probeDecode() (err error) {
+	defer func() {
+		rewindErr := video.media.rewindStart()
+		C.avcodec_flush_buffers(video.codecCtx)
+		video.freeDeinterlacer()
+		video.freeHWScaler()
+
+		if err == nil {
+			err = rewindErr
+		}
+	}()
+
+	decoded := false
+
+	for i := 0; i < maxProbePackets && !decoded; i++ {
+		pkt, gotPacket, err := video.media.ReadPacket()
+
+		if err != nil && !isEndOfFile(err) {
+			return err
+		}
+
+		if !gotPacket {
+			break
+		}
+
+		if pkt == nil || pkt.StreamIndex() != video.Index() {
+			continue
+		}
+
+		src, ok, err := video.decodeFrame()
+
+		if err != nil && !isEndOfFile(err) {
+			return err
+		}
+
+		if !ok {
+			break
+		}
+
+		if src == nil {
+			continue
+		}
+
+		// The decoder falls back to the software
+		// pixel format if the device can't decode
+		// the stream, and the device isn't used then.
+		if C.enum_AVPixelFormat(video.frame.format) != video.hwPixFmt {
+			return fmt.Errorf(
+				"the frame isn't decoded on the hardware device")
+		}
+
+		decoded = true
+	}
+
+	if !decoded {
+		return fmt.Errorf(
+			"couldn't decode a frame on the hardware device")
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package reisen
+
+// #cgo pkg-config: libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavutil/hwcontext.h>
+import "C"
+import "fmt"
+
+// HWDeviceType is a type of the hardware
+// device used for decoding video frames.
+type HWDeviceType int
+
+const (
+	HWDeviceNone         HWDeviceType = C.AV_HWDEVICE_TYPE_NONE
+	HWDeviceCUDA         HWDeviceType = C.AV_HWDEVICE_TYPE_CUDA
+	HWDeviceVAAPI        HWDeviceType = C.AV_HWDEVICE_TYPE_VAAPI
+	HWDeviceVDPAU        HWDeviceType = C.AV_HWDEVICE_TYPE_VDPAU
+	HWDeviceQSV          HWDeviceType = C.AV_HWDEVICE_TYPE_QSV
+	HWDeviceVideoToolbox HWDeviceType = C.AV_HWDEVICE_TYPE_VIDEOTOOLBOX
+	HWDeviceD3D11VA      HWDeviceType = C.AV_HWDEVICE_TYPE_D3D11VA
+	HWDeviceDXVA2        HWDeviceType = C.AV_HWDEVICE_TYPE_DXVA2
+	HWDeviceVulkan       HWDeviceType = C.AV_HWDEVICE_TYPE_VULKAN
+)
+
+// String returns the name of the
+// hardware device type (e.g., "cuda").
+func (deviceType HWDeviceType) String() string {
+	name := C.av_hwdevice_get_type_name(
+		C.enum_AVHWDeviceType(deviceType))
+
+	if name == nil {
+		return ""
+	}
+
+	return C.GoString(name)
+}
+
+// HWPixelFormats returns the pixel formats in which
+// the decoder of the stream outputs the frames on the
+// hardware device of the specified type. It's empty
+// if the decoder doesn't support the device.
+func (video *VideoStream) HWPixelFormats(deviceType HWDeviceType) []PixelFormat {
+	formats := []PixelFormat{}
+
+	for i := C.int(0); ; i++ {
+		config := C.avcodec_get_hw_config(video.codec, i)
+
+		if config == nil {
+			break
+		}
+
+		if config.device_type != C.enum_AVHWDeviceType(deviceType) ||
+			config.methods&(C.AV_CODEC_HW_CONFIG_METHOD_HW_DEVICE_CTX|
+				C.AV_CODEC_HW_CONFIG_METHOD_HW_FRAMES_CTX) == 0 {
+			continue
+		}
+
+		format := PixelFormat(config.pix_fmt)
+		duplicate := false
+
+		for _, known := range formats {
+			if known == format {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			formats = append(formats, format)
+		}
+	}
+
+	return formats
+}
+
+// HWDeviceTypes returns the types of the hardware
+// devices supported by the libAV build. A device of
+// the type may still be missing on the host.
+func HWDeviceTypes() []HWDeviceType {
+	types := []HWDeviceType{}
+	deviceType := C.enum_AVHWDeviceType(C.AV_HWDEVICE_TYPE_NONE)
+
+	for {
+		deviceType = C.av_hwdevice_iterate_types(deviceType)
+
+		if deviceType == C.AV_HWDEVICE_TYPE_NONE {
+			break
+		}
+
+		types = append(types, HWDeviceType(deviceType))
+	}
+
+	return types
+}
+
+// OpenDecodeHW opens the video stream for decoding
+// the frames on the hardware device of the specified
+// type. The decoded frames are transferred to the
+// system memory and scaled to the specified width and
+// height using the interpolation algorithm.
+func (video *VideoStream) OpenDecodeHW(deviceType HWDeviceType, width, height int, alg InterpolationAlgorithm) error {
+	if deviceType == HWDeviceNone {
+		return fmt.Errorf(
+			"no hardware device type is specified")
+	}
+
+	video.hwDeviceType = C.enum_AVHWDeviceType(deviceType)
+	err := video.OpenDecode(width, height, alg)
+
+	if err != nil {
+		video.Close()
+		video.hwDeviceType = C.AV_HWDEVICE_TYPE_NONE
+
+		return err
+	}
+
+	return nil
+}
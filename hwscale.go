@@ -0,0 +1,114 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/frame.h>
+// #include <libavutil/hwcontext.h>
+import "C"
+import "fmt"
+
+// hwScaleFilters are the scaling filters working on
+// the frames of the hardware devices in the order
+// of preference.
+var hwScaleFilters = map[string][]string{
+	"cuda":         {"scale_cuda", "scale_npp"},
+	"vaapi":        {"scale_vaapi"},
+	"qsv":          {"scale_qsv"},
+	"videotoolbox": {"scale_vt"},
+}
+
+// SetHWScaling enables or disables scaling the frames
+// decoded on the hardware device to the output size
+// on the device itself, so the frames are transferred
+// to the CPU already scaled. It must be called before
+// opening the stream for decoding.
+//
+// If the device has no scaling filter, the
+// frames are scaled on the CPU as usual.
+func (video *VideoStream) SetHWScaling(enabled bool) {
+	video.hwScale = enabled
+}
+
+// HWScaling returns 'true' if the frames of
+// the stream are scaled on the hardware device.
+func (video *VideoStream) HWScaling() bool {
+	return video.hwScaleGraph != nil
+}
+
+// hwScaleFilter returns the name of the scaling
+// filter available for the hardware device
+// or "" if there's none.
+func (video *VideoStream) hwScaleFilter() string {
+	name := C.GoString(C.av_hwdevice_get_type_name(
+		video.hwDeviceType))
+
+	for _, filter := range hwScaleFilters[name] {
+		if filterExists(filter) {
+			return filter
+		}
+	}
+
+	return ""
+}
+
+// hwScaleFrame scales the frame decoded on the hardware
+// device to the output size without leaving the device.
+// It returns false if the scaler needs more frames.
+func (video *VideoStream) hwScaleFrame(frame *C.AVFrame) (*C.AVFrame, bool, error) {
+	if !video.hwScale || video.hwFrame == nil ||
+		C.enum_AVPixelFormat(frame.format) != video.hwPixFmt ||
+		frame.hw_frames_ctx == nil {
+		return frame, true, nil
+	}
+
+	if int(frame.width) == video.dstWidth &&
+		int(frame.height) == video.dstHeight {
+		return frame, true, nil
+	}
+
+	srcArgs := video.frameBufferArgs(frame)
+
+	// The scaler is recreated when the size
+	// of the frames changes in the middle
+	// of the stream.
+	if video.hwScaleGraph == nil || video.hwScaleArgs != srcArgs {
+		video.freeHWScaler()
+		filter := video.hwScaleFilter()
+
+		// Fall back to scaling on the CPU.
+		if filter == "" {
+			video.hwScale = false
+			return frame, true, nil
+		}
+
+		description := fmt.Sprintf("%s=w=%d:h=%d",
+			filter, video.dstWidth, video.dstHeight)
+		graph, err := newHWFilterGraph(description,
+			srcArgs, frame.hw_frames_ctx)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		video.hwScaleGraph = graph
+		video.hwScaleArgs = srcArgs
+	}
+
+	err := video.hwScaleGraph.push(frame)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return video.hwScaleGraph.pull()
+}
+
+// freeHWScaler frees the filter graph
+// of the hardware scaler.
+func (video *VideoStream) freeHWScaler() {
+	if video.hwScaleGraph != nil {
+		video.hwScaleGraph.free()
+		video.hwScaleGraph = nil
+	}
+
+	video.hwScaleArgs = ""
+}
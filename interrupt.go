@@ -0,0 +1,135 @@
+package reisen
+
+// #cgo pkg-config: libavformat
+// #include <libavformat/avformat.h>
+// #include <stdlib.h>
+//
+// typedef struct interrupt_state {
+//     volatile int interrupted;
+//     volatile int stopped;
+// } interrupt_state;
+//
+// static int interrupt_callback(void *opaque) {
+//     interrupt_state *state = opaque;
+//
+//     return state->interrupted || state->stopped;
+// }
+//
+// static void set_interrupt_callback(AVFormatContext *ctx,
+//     interrupt_state *state) {
+//     ctx->interrupt_callback.callback = interrupt_callback;
+//     ctx->interrupt_callback.opaque = state;
+// }
+//
+// static void set_interrupted(interrupt_state *state, int interrupted) {
+//     state->interrupted = interrupted;
+// }
+//
+// static void set_stopped(interrupt_state *state, int stopped) {
+//     state->stopped = stopped;
+// }
+import "C"
+import (
+	"context"
+	"unsafe"
+)
+
+// interruptState is the flag checked by libAV to
+// abort the blocking operations of the media.
+type interruptState C.interrupt_state
+
+// newInterruptState allocates a new interrupt flag
+// in the C memory, so libAV can read it any time.
+func newInterruptState() *interruptState {
+	return (*interruptState)(C.calloc(1,
+		C.size_t(unsafe.Sizeof(C.interrupt_state{}))))
+}
+
+// freeInterruptState frees the interrupt flag.
+func freeInterruptState(state *interruptState) {
+	C.free(unsafe.Pointer(state))
+}
+
+// setInterruptCallback makes the media context
+// check the interrupt flag.
+func setInterruptCallback(ctx *C.AVFormatContext, state *interruptState) {
+	C.set_interrupt_callback(ctx, (*C.interrupt_state)(state))
+}
+
+// setInterrupted raises or clears the interrupt flag.
+func setInterrupted(state *interruptState, interrupted bool) {
+	value := C.int(0)
+
+	if interrupted {
+		value = 1
+	}
+
+	C.set_interrupted((*C.interrupt_state)(state), value)
+}
+
+// setStopped raises or clears the flag aborting
+// the blocking operations until it's cleared
+// regardless of the watched contexts.
+func setStopped(state *interruptState, stopped bool) {
+	value := C.int(0)
+
+	if stopped {
+		value = 1
+	}
+
+	C.set_stopped((*C.interrupt_state)(state), value)
+}
+
+// Interrupt aborts the blocking operation of the media
+// in progress (e.g., ReadPacket stuck on a hung network
+// stream) from another goroutine, and all the following
+// ones fail promptly until ResetInterrupt is called.
+func (media *Media) Interrupt() {
+	if media.interrupt != nil {
+		setStopped(media.interrupt, true)
+	}
+}
+
+// ResetInterrupt allows the blocking operations
+// of the media again after Interrupt. The packet
+// prefetching stopped by the interruption (see
+// Options.ReadAheadPackets) is restarted, so it
+// must be called on the goroutine reading the
+// media.
+func (media *Media) ResetInterrupt() {
+	if media.interrupt != nil {
+		setStopped(media.interrupt, false)
+	}
+
+	media.restartReadAhead()
+}
+
+// watchInterrupt raises the interrupt flag once the
+// context is done, which aborts the blocking libAV
+// operations. The returned function stops watching
+// the context and clears the flag.
+func watchInterrupt(ctx context.Context, state *interruptState) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		select {
+		case <-ctx.Done():
+			setInterrupted(state, true)
+
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		setInterrupted(state, false)
+	}
+}
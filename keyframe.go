@@ -0,0 +1,61 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import (
+	"fmt"
+	"time"
+)
+
+// SeekKeyframe rewinds the whole media to the nearest
+// keyframe of the video stream preceding the specified
+// time location, resets the decoder and returns the
+// time location of the keyframe. The frames up to the
+// exact target can be decoded and skipped then.
+func (video *VideoStream) SeekKeyframe(t time.Duration) (time.Duration, error) {
+	tbNum, tbDen := video.TimeBase()
+	ts := int64(t.Seconds() * float64(tbDen) / float64(tbNum))
+
+	status := video.media.seek(video.inner.index,
+		ts, C.AVSEEK_FLAG_BACKWARD)
+
+	if status < 0 {
+		return 0, newAVError(status,
+			"couldn't seek the stream")
+	}
+
+	err := video.media.resetStreams()
+
+	if err != nil {
+		return 0, err
+	}
+
+	// The first packet of the stream after
+	// the seek holds the keyframe.
+	keyframe := noPTS
+
+	video.media.peekPackets(func(packet *C.AVPacket) bool {
+		if packet.stream_index != video.inner.index {
+			return true
+		}
+
+		keyframe = int64(packet.pts)
+
+		if keyframe == noPTS {
+			keyframe = int64(packet.dts)
+		}
+
+		return false
+	})
+
+	if keyframe == noPTS {
+		return 0, fmt.Errorf(
+			"couldn't find the keyframe timestamp")
+	}
+
+	tm := float64(keyframe) * float64(tbNum) / float64(tbDen)
+
+	return time.ParseDuration(fmt.Sprintf("%fs", tm))
+}
@@ -0,0 +1,75 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/log.h>
+// #include <stdarg.h>
+// #include <stdio.h>
+//
+// #define LOG_LINE_SIZE 1024
+//
+// extern void logMessage(int level, char *msg);
+//
+// static void log_callback(void *ptr, int level, const char *fmt, va_list vl) {
+//     char line[LOG_LINE_SIZE];
+//
+//     if (level > av_log_get_level())
+//         return;
+//
+//     vsnprintf(line, sizeof(line), fmt, vl);
+//     logMessage(level, line);
+// }
+//
+// static void set_log_callback(int enabled) {
+//     av_log_set_callback(enabled ? log_callback : av_log_default_callback);
+// }
+import "C"
+import "sync"
+
+// LogLevel is the level of
+// the libAV log messages.
+type LogLevel int
+
+const (
+	LogQuiet   LogLevel = C.AV_LOG_QUIET
+	LogPanic   LogLevel = C.AV_LOG_PANIC
+	LogFatal   LogLevel = C.AV_LOG_FATAL
+	LogError   LogLevel = C.AV_LOG_ERROR
+	LogWarning LogLevel = C.AV_LOG_WARNING
+	LogInfo    LogLevel = C.AV_LOG_INFO
+	LogVerbose LogLevel = C.AV_LOG_VERBOSE
+	LogDebug   LogLevel = C.AV_LOG_DEBUG
+	LogTrace   LogLevel = C.AV_LOG_TRACE
+)
+
+var (
+	logMutex    sync.RWMutex
+	logCallback func(level int, msg string)
+)
+
+// SetLogCallback routes the log messages of libAV
+// (printed to stderr by default) to the function,
+// e.g., to a structured logger. The messages above
+// the log level are dropped. The default logging
+// is restored if fn is nil.
+//
+// The function may be called from the threads
+// of libAV concurrently, so it must be safe
+// for the concurrent use.
+func SetLogCallback(fn func(level int, msg string)) {
+	logMutex.Lock()
+	logCallback = fn
+	logMutex.Unlock()
+
+	if fn != nil {
+		C.set_log_callback(1)
+	} else {
+		C.set_log_callback(0)
+	}
+}
+
+// SetLogLevel sets the maximum level
+// of the libAV log messages to print
+// (LogInfo by default).
+func SetLogLevel(level LogLevel) {
+	C.av_log_set_level(C.int(level))
+}
@@ -0,0 +1,24 @@
+package reisen
+
+import "C"
+import "strings"
+
+// logMessage passes the formatted libAV
+// log message to the Go log callback.
+//
+//export logMessage
+func logMessage(level C.int, msg *C.char) {
+	logMutex.RLock()
+	fn := logCallback
+	logMutex.RUnlock()
+
+	if fn == nil {
+		return
+	}
+
+	text := strings.TrimRight(C.GoString(msg), "\n")
+
+	if text != "" {
+		fn(int(level), text)
+	}
+}
@@ -9,7 +9,9 @@ package reisen
 import "C"
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 	"unsafe"
 )
@@ -17,9 +19,34 @@ import (
 // Media is a media file containing
 // audio, video and other types of streams.
 type Media struct {
-	ctx     *C.AVFormatContext
-	packet  *C.AVPacket
-	streams []Stream
+	ctx      *C.AVFormatContext
+	filename string
+	packet   *C.AVPacket
+	streams  []Stream
+	options  Options
+	prefetch *readAhead
+	pending  []prefetchedPacket
+	paused   bool
+	// The lock of the format context taken by
+	// the read-ahead goroutine while reading
+	// a packet and by the accessors of the
+	// context state it changes.
+	ctxMu sync.Mutex
+	// The total number of the decoded
+	// frames and the time spent on it.
+	framesDecoded int64
+	decodeTime    time.Duration
+	segmenter     *segmenter
+	// The total size of the packets read.
+	bytesRead int64
+	// The custom I/O context of the media
+	// and the function to free it.
+	memoryIO *C.AVIOContext
+	readerIO bool
+	closeIO  func()
+	// The flag aborting the blocking
+	// operations of the media.
+	interrupt *interruptState
 }
 
 // StreamCount returns the number of streams.
@@ -64,15 +91,98 @@ func (media *Media) AudioStreams() []*AudioStream {
 	return audioStreams
 }
 
+// VideoStream returns the video stream
+// with the specified index.
+func (media *Media) VideoStream(index int) (*VideoStream, error) {
+	if index < 0 || index >= len(media.streams) {
+		return nil, fmt.Errorf(
+			"there's no stream with the index %d", index)
+	}
+
+	videoStream, ok := media.streams[index].(*VideoStream)
+
+	if !ok {
+		return nil, fmt.Errorf(
+			"the stream %d is not a video stream", index)
+	}
+
+	return videoStream, nil
+}
+
+// AudioStream returns the audio stream
+// with the specified index.
+func (media *Media) AudioStream(index int) (*AudioStream, error) {
+	if index < 0 || index >= len(media.streams) {
+		return nil, fmt.Errorf(
+			"there's no stream with the index %d", index)
+	}
+
+	audioStream, ok := media.streams[index].(*AudioStream)
+
+	if !ok {
+		return nil, fmt.Errorf(
+			"the stream %d is not an audio stream", index)
+	}
+
+	return audioStream, nil
+}
+
+// BestVideoStream returns the video stream chosen by
+// libAV as the best one (considering the default
+// disposition, the resolution, etc.). It returns
+// ErrStreamNotFound if there are no video streams.
+func (media *Media) BestVideoStream() (*VideoStream, error) {
+	media.ctxMu.Lock()
+	index := C.av_find_best_stream(media.ctx,
+		C.AVMEDIA_TYPE_VIDEO, -1, -1, nil, 0)
+	media.ctxMu.Unlock()
+
+	if index < 0 {
+		return nil, ErrStreamNotFound
+	}
+
+	return media.VideoStream(int(index))
+}
+
+// BestAudioStream returns the audio stream chosen by
+// libAV as the best one (considering the default
+// disposition, the channel count, etc.). It returns
+// ErrStreamNotFound if there are no audio streams.
+func (media *Media) BestAudioStream() (*AudioStream, error) {
+	media.ctxMu.Lock()
+	index := C.av_find_best_stream(media.ctx,
+		C.AVMEDIA_TYPE_AUDIO, -1, -1, nil, 0)
+	media.ctxMu.Unlock()
+
+	if index < 0 {
+		return nil, ErrStreamNotFound
+	}
+
+	return media.AudioStream(int(index))
+}
+
 // Duration returns the overall duration
 // of the media file.
 func (media *Media) Duration() (time.Duration, error) {
-	dur := media.ctx.duration
+	dur := media.DurationTimeBase()
 	tm := float64(dur) / float64(TimeBase)
 
 	return time.ParseDuration(fmt.Sprintf("%fs", tm))
 }
 
+// DurationTimeBase returns the overall duration
+// of the media file in AV_TIME_BASE units
+// (see TimeBase) or 0 if it's unknown.
+func (media *Media) DurationTimeBase() int64 {
+	dur := int64(media.ctx.duration)
+
+	if dur < 0 {
+		dur = 0
+	}
+
+	return dur
+}
+
 // FormatName returns the name of the media format.
 func (media *Media) FormatName() string {
 	if media.ctx.iformat.name == nil {
@@ -102,10 +212,19 @@ func (media *Media) FormatMIMEType() string {
 	return C.GoString(media.ctx.iformat.mime_type)
 }
 
+// Metadata returns the tags of the media
+// container (e.g., title, artist, encoder
+// or creation_time) with the lowercase keys.
+func (media *Media) Metadata() map[string]string {
+	media.ctxMu.Lock()
+	defer media.ctxMu.Unlock()
+
+	return dictionaryEntries(media.ctx.metadata)
+}
+
 // findStreams retrieves the stream information
 // from the media container.
 func (media *Media) findStreams() error {
-	streams := []Stream{}
 	status := C.avformat_find_stream_info(media.ctx, nil)
 
 	if status < 0 {
@@ -113,6 +232,15 @@ func (media *Media) findStreams() error {
 			"couldn't find stream information")
 	}
 
+	media.initStreams()
+
+	return nil
+}
+
+// initStreams creates the stream objects
+// for the streams of the media container.
+func (media *Media) initStreams() {
+	streams := []Stream{}
 	innerStreams := unsafe.Slice(
 		media.ctx.streams, media.ctx.nb_streams)
 
@@ -150,6 +278,15 @@ func (media *Media) findStreams() error {
 
 			streams = append(streams, audioStream)
 
+		case C.AVMEDIA_TYPE_SUBTITLE:
+			subtitleStream := new(SubtitleStream)
+			subtitleStream.inner = innerStream
+			subtitleStream.codecParams = codecParams
+			subtitleStream.codec = codec
+			subtitleStream.media = media
+
+			streams = append(streams, subtitleStream)
+
 		default:
 			unknownStream := new(UnknownStream)
 			unknownStream.inner = innerStream
@@ -163,9 +300,51 @@ func (media *Media) findStreams() error {
 
 	media.streams = streams
 
+	if len(media.options.WantedStreams) > 0 {
+		media.discardStreams(media.options.WantedStreams)
+	}
+}
+
+// SelectStreams makes the demuxer read only the
+// packets of the streams with the specified indices
+// and skip the others internally, so ReadPacket
+// doesn't return them. All the streams are selected
+// again if indices is empty.
+func (media *Media) SelectStreams(indices []int) error {
+	for _, index := range indices {
+		if index < 0 || index >= len(media.streams) {
+			return fmt.Errorf(
+				"there's no stream with the index %d", index)
+		}
+	}
+
+	media.ctxMu.Lock()
+	media.discardStreams(indices)
+	media.ctxMu.Unlock()
+
 	return nil
 }
 
+// discardStreams discards all the packets of the
+// streams except the ones with the specified indices.
+func (media *Media) discardStreams(indices []int) {
+	wanted := map[int]bool{}
+
+	for _, index := range indices {
+		wanted[index] = true
+	}
+
+	for _, stream := range media.streams {
+		inner := stream.innerStream()
+
+		if len(wanted) == 0 || wanted[stream.Index()] {
+			inner.discard = C.AVDISCARD_DEFAULT
+		} else {
+			inner.discard = C.AVDISCARD_ALL
+		}
+	}
+}
+
 // OpenDecode opens the media container for decoding.
 //
 // CloseDecode() should be called afterwards.
@@ -177,22 +356,37 @@ func (media *Media) OpenDecode() error {
 			"couldn't allocate a new packet")
 	}
 
+	media.startReadAhead()
+
 	return nil
 }
 
 // ReadPacket reads the next packet from the media stream.
+// Upon reaching the end of the media, it returns false
+// and an error satisfying errors.Is(err, io.EOF).
 func (media *Media) ReadPacket() (*Packet, bool, error) {
-	status := C.av_read_frame(media.ctx, media.packet)
+	status := media.readFrame()
 
 	if status < 0 {
 		if status == C.int(ErrorAgain) {
+			if media.paused {
+				return nil, false, ErrPaused
+			}
+
 			return nil, true, nil
 		}
 
 		// No packets anymore.
-		return nil, false, nil
+		if status == C.int(ErrorEndOfFile) {
+			return nil, false, media.endOfFile()
+		}
+
+		return nil, false, newAVError(status,
+			"couldn't read the packet")
 	}
 
+	media.bytesRead += int64(media.packet.size)
+
 	// Filter the packet if needed.
 	packetStream := media.streams[media.packet.stream_index]
 	outPacket := media.packet
@@ -206,35 +400,163 @@ func (media *Media) ReadPacket() (*Packet, bool, error) {
 
 		if status < 0 {
 			return nil, false,
-				fmt.Errorf("%d: couldn't reference the packet",
-					status)
+				newAVError(status, "couldn't reference the packet")
 		}
 
 		status = C.av_bsf_send_packet(filter, packetIn)
 
 		if status < 0 {
 			return nil, false,
-				fmt.Errorf("%d: couldn't send the packet to the filter",
-					status)
+				newAVError(status, "couldn't send the packet to the filter")
 		}
 
 		status = C.av_bsf_receive_packet(filter, packetOut)
 
 		if status < 0 {
 			return nil, false,
-				fmt.Errorf("%d: couldn't receive the packet from the filter",
-					status)
+				newAVError(status, "couldn't receive the packet from the filter")
 		}
 
 		outPacket = packetOut
 	}
 
-	return newPacket(media, outPacket), true, nil
+	pkt := newPacket(media, outPacket)
+
+	if media.segmenter != nil {
+		pkt.boundary = media.segmenter.boundary(pkt)
+	}
+
+	return pkt, true, nil
+}
+
+// ReadPacketContext reads the next packet from the
+// media stream like ReadPacket, but the read blocked
+// by the input (e.g., a network stream gone silent)
+// is aborted once the context is done, and the error
+// of the context is returned then.
+//
+// If the read-ahead is enabled, aborting
+// the read stops prefetching the packets.
+func (media *Media) ReadPacketContext(ctx context.Context) (*Packet, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	stop := watchInterrupt(ctx, media.interrupt)
+	pkt, ok, err := media.ReadPacket()
+	stop()
+
+	if !ok && ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
+	return pkt, ok, err
+}
+
+// flushFilters resets the state of the bitstream
+// filters of the streams, so the packets read after
+// rewinding the media are filtered from scratch.
+func (media *Media) flushFilters() {
+	for _, stream := range media.streams {
+		if filter := stream.filter(); filter != nil {
+			C.av_bsf_flush(filter)
+		}
+	}
+}
+
+// SeekByte moves the media to the specified byte
+// position. It's a low-level escape hatch for the raw
+// and streamed formats without reliable timestamps
+// where Rewind fails (e.g., the duration is unknown).
+// The stream index may be -1 as the position doesn't
+// depend on the time base of any stream.
+//
+// The position isn't required to be on a packet
+// boundary, so the decoders may need a few packets
+// to resynchronize. The decoding state of all the
+// streams is reset afterwards like after rewinding.
+func (media *Media) SeekByte(streamIndex int, pos int64) error {
+	if streamIndex < -1 || streamIndex >= len(media.streams) {
+		return fmt.Errorf(
+			"there's no stream with the index %d", streamIndex)
+	}
+
+	status := media.seek(C.int(streamIndex), pos, C.AVSEEK_FLAG_BYTE)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't seek to the byte position")
+	}
+
+	return media.resetStreams()
+}
+
+// resetStreams drops the decoding state of
+// all the streams buffered from the previous
+// position after the media is seeked.
+func (media *Media) resetStreams() error {
+	for _, stream := range media.streams {
+		err := stream.reset()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BytesRead returns the total size of the packets
+// read from the media so far, e.g., to show the
+// progress of processing the whole file along
+// with FileSize. The packets read again after
+// rewinding the media are counted too.
+func (media *Media) BytesRead() int64 {
+	return media.bytesRead
+}
+
+// FileSize returns the size of the media
+// file in bytes or -1 if it's unknown
+// (e.g., for a live stream).
+func (media *Media) FileSize() int64 {
+	media.ctxMu.Lock()
+	defer media.ctxMu.Unlock()
+
+	if media.ctx.pb == nil {
+		return -1
+	}
+
+	size := int64(C.avio_size(media.ctx.pb))
+
+	if size < 0 {
+		return -1
+	}
+
+	return size
+}
+
+// Seekable returns true if the input of the media can
+// be seeked, e.g., the HTTP server of a remote file
+// accepts range requests, so rewinding the media
+// doesn't require downloading the data until the
+// target position. It's false for the demuxers
+// without a byte stream input (e.g., RTSP) which
+// may still support seeking on their own.
+func (media *Media) Seekable() bool {
+	media.ctxMu.Lock()
+	defer media.ctxMu.Unlock()
+
+	if media.ctx.pb == nil {
+		return false
+	}
+
+	return media.ctx.pb.seekable&C.AVIO_SEEKABLE_NORMAL != 0
 }
 
 // CloseDecode closes the media container for decoding.
 func (media *Media) CloseDecode() error {
-	C.av_free(unsafe.Pointer(media.packet))
+	media.stopReadAhead(true)
+	C.av_packet_free(&media.packet)
 	media.packet = nil
 
 	return nil
@@ -242,35 +564,252 @@ func (media *Media) CloseDecode() error {
 
 // Close closes the media container.
 func (media *Media) Close() {
-	C.avformat_free_context(media.ctx)
+	media.stopReadAhead(true)
+	C.avformat_close_input(&media.ctx)
 	media.ctx = nil
+	media.freeIO()
+
+	if media.interrupt != nil {
+		freeInterruptState(media.interrupt)
+		media.interrupt = nil
+	}
+}
+
+// Clone opens the same media file once again
+// and returns a new media container with its own
+// streams and decoding position. It's a real second
+// open of the file, nothing is shared with the
+// original media container, and its streams and
+// decoding must be opened and closed separately.
+func (media *Media) Clone() (*Media, error) {
+	opts := media.options
+
+	if media.readerIO {
+		return nil, fmt.Errorf(
+			"the media read from a reader can't be cloned")
+	}
+
+	if media.memoryIO != nil {
+		return NewMediaFromBytes(memoryAVIOData(media.memoryIO), &opts)
+	}
+
+	return NewMediaWithOptions(media.filename, &opts)
 }
 
 // NewMedia returns a new media container analyzer
 // for the specified media file.
 func NewMedia(filename string) (*Media, error) {
+	return NewMediaWithOptions(filename, nil)
+}
+
+// NewMediaWithOptions returns a new media container
+// analyzer for the specified media file opened with
+// the given options (nil means the defaults).
+func NewMediaWithOptions(filename string, opts *Options) (*Media, error) {
+	return NewMediaContext(context.Background(), filename, opts)
+}
+
+// NewMediaContext returns a new media container
+// analyzer for the specified media file opened with
+// the given options (nil means the defaults).
+//
+// Opening the media is aborted once the context is
+// done. The failed attempts to open the media are
+// retried according to the options.
+func NewMediaContext(ctx context.Context, filename string, opts *Options) (*Media, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	interrupt := newInterruptState()
+	stop := watchInterrupt(ctx, interrupt)
+	backoff := opts.OpenRetryBackoff
+
+	if backoff <= 0 {
+		backoff = defaultOpenRetryBackoff
+	}
+
+	var media *Media
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		media, err = openMediaIO(filename, nil, nil,
+			interrupt, opts, nil)
+
+		if err == nil || attempt >= opts.OpenRetries ||
+			ctx.Err() != nil || !isTransientError(err) {
+			break
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+
+		backoff *= 2
+
+		if backoff > maxOpenRetryBackoff {
+			backoff = maxOpenRetryBackoff
+		}
+	}
+
+	if err == nil {
+		err = media.findStreams()
+
+		if err != nil {
+			stop()
+			media.Close()
+
+			return nil, err
+		}
+	}
+
+	stop()
+
+	if err != nil {
+		freeInterruptState(interrupt)
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// ProbeFast opens the specified media file reading
+// as little data as possible and returns the media
+// container analyzer with the metadata declared in
+// the header of the file.
+//
+// The stream information isn't analyzed, so some of
+// the stream parameters (e.g., the duration, the
+// frame rate or the frame count) may be missing.
+// It's meant for a quick first-pass scan.
+func ProbeFast(filename string) (*Media, error) {
+	media, err := openMedia(filename, nil, map[string]string{
+		"probesize":       "32",
+		"analyzeduration": "0",
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	media.initStreams()
+
+	return media, nil
+}
+
+// openMedia opens the media container
+// with the specified demuxer options.
+func openMedia(filename string, opts *Options, demuxerOpts map[string]string) (*Media, error) {
+	interrupt := newInterruptState()
+	media, err := openMediaIO(filename, nil, nil,
+		interrupt, opts, demuxerOpts)
+
+	if err != nil {
+		freeInterruptState(interrupt)
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// openMediaIO opens the media container reading
+// the data from the custom I/O context if it's
+// specified. The I/O context is freed with closeIO
+// when the media is closed or fails to open.
+//
+// The blocking operations of the media are aborted
+// once the interrupt flag is raised. The media owns
+// the flag if it's opened successfully.
+func openMediaIO(filename string, pb *C.AVIOContext, closeIO func(), interrupt *interruptState, opts *Options, demuxerOpts map[string]string) (*Media, error) {
 	media := &Media{
-		ctx: C.avformat_alloc_context(),
+		ctx:       C.avformat_alloc_context(),
+		filename:  filename,
+		closeIO:   closeIO,
+		interrupt: interrupt,
+	}
+
+	if opts != nil {
+		media.options = *opts
 	}
 
 	if media.ctx == nil {
+		media.freeIO()
+
 		return nil, fmt.Errorf(
 			"couldn't create a new media context")
 	}
 
-	fname := C.CString(filename)
-	status := C.avformat_open_input(&media.ctx, fname, nil, nil)
+	if pb != nil {
+		media.ctx.pb = pb
+	}
 
-	if status < 0 {
-		return nil, fmt.Errorf(
-			"couldn't open file %s", filename)
+	setInterruptCallback(media.ctx, interrupt)
+
+	values := media.options.demuxerOptions()
+
+	for key, value := range demuxerOpts {
+		values[key] = value
 	}
 
-	C.free(unsafe.Pointer(fname))
-	err := media.findStreams()
+	var inputFormat *C.AVInputFormat
+
+	if media.options.InputFormat != "" {
+		name := C.CString(media.options.InputFormat)
+		inputFormat = C.av_find_input_format(name)
+		C.free(unsafe.Pointer(name))
+
+		if inputFormat == nil {
+			C.avformat_free_context(media.ctx)
+			media.freeIO()
+
+			return nil, fmt.Errorf(
+				"couldn't find the input format %s",
+				media.options.InputFormat)
+		}
+	}
+
+	dict, err := newDictionary(values)
+
 	if err != nil {
+		C.avformat_free_context(media.ctx)
+		media.freeIO()
+
 		return nil, err
 	}
 
+	defer C.av_dict_free(&dict)
+
+	fname := C.CString(media.options.inputURL(filename))
+	defer C.free(unsafe.Pointer(fname))
+	status := C.avformat_open_input(&media.ctx,
+		fname, inputFormat, &dict)
+
+	if status < 0 {
+		// The media context is freed
+		// by libAV on failure.
+		media.freeIO()
+
+		return nil, newAVError(status,
+			fmt.Sprintf("couldn't open file %s", filename))
+	}
+
 	return media, nil
 }
+
+// freeIO frees the custom I/O
+// context of the media if any.
+func (media *Media) freeIO() {
+	if media.closeIO != nil {
+		media.closeIO()
+		media.closeIO = nil
+	}
+}
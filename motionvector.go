@@ -0,0 +1,96 @@
+package reisen
+
+// #cgo pkg-config: libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavutil/frame.h>
+// #include <libavutil/motion_vector.h>
+import "C"
+import "unsafe"
+
+// MotionVector is a motion vector of a block
+// of a video frame exported by the decoder.
+type MotionVector struct {
+	// Source is negative if the block is predicted
+	// from a past frame and positive if it's
+	// predicted from a future frame.
+	Source int
+	// Width is the width of the block.
+	Width int
+	// Height is the height of the block.
+	Height int
+	// SrcX is the X coordinate of the
+	// block center in the reference frame.
+	SrcX int
+	// SrcY is the Y coordinate of the
+	// block center in the reference frame.
+	SrcY int
+	// DstX is the X coordinate of the
+	// block center in the current frame.
+	DstX int
+	// DstY is the Y coordinate of the
+	// block center in the current frame.
+	DstY int
+	// MotionX is the X component of the motion
+	// vector in units of 1/MotionScale pixels.
+	MotionX int
+	// MotionY is the Y component of the motion
+	// vector in units of 1/MotionScale pixels.
+	MotionY int
+	// MotionScale is the denominator
+	// of the motion vector components.
+	MotionScale int
+}
+
+// SetExportMotionVectors makes the decoder export
+// the motion vectors of the decoded frames, so they
+// are available with VideoFrame.MotionVectors.
+//
+// It must be called before the stream is opened.
+func (video *VideoStream) SetExportMotionVectors(enabled bool) {
+	if enabled {
+		video.codecFlags2 |= C.AV_CODEC_FLAG2_EXPORT_MVS
+	} else {
+		video.codecFlags2 &^= C.AV_CODEC_FLAG2_EXPORT_MVS
+	}
+}
+
+// MotionVectors returns the motion vectors of the
+// frame if their export is enabled for the stream
+// and the codec supports it.
+func (frame *VideoFrame) MotionVectors() []MotionVector {
+	return frame.motionVectors
+}
+
+// frameMotionVectors returns the motion vectors
+// from the side data of the decoded frame.
+func frameMotionVectors(frame *C.AVFrame) []MotionVector {
+	sideData := C.av_frame_get_side_data(frame,
+		C.AV_FRAME_DATA_MOTION_VECTORS)
+
+	if sideData == nil || sideData.data == nil {
+		return nil
+	}
+
+	count := uintptr(sideData.size) /
+		unsafe.Sizeof(C.AVMotionVector{})
+	avVectors := unsafe.Slice((*C.AVMotionVector)(
+		unsafe.Pointer(sideData.data)), count)
+	vectors := make([]MotionVector, len(avVectors))
+
+	for i, avVector := range avVectors {
+		vectors[i] = MotionVector{
+			Source:      int(avVector.source),
+			Width:       int(avVector.w),
+			Height:      int(avVector.h),
+			SrcX:        int(avVector.src_x),
+			SrcY:        int(avVector.src_y),
+			DstX:        int(avVector.dst_x),
+			DstY:        int(avVector.dst_y),
+			MotionX:     int(avVector.motion_x),
+			MotionY:     int(avVector.motion_y),
+			MotionScale: int(avVector.motion_scale),
+		}
+	}
+
+	return vectors
+}
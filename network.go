@@ -1,6 +1,7 @@
 package reisen
 
 // #include <libavformat/avformat.h>
+// #include <errno.h>
 import "C"
 import "fmt"
 
@@ -23,3 +24,65 @@ func NetworkDeinitialize() error {
 
 	return nil
 }
+
+// Pause stops reading the media container
+// without closing it, e.g., when the playback
+// buffer is full. The packets already read
+// ahead are still served by ReadPacket, and
+// then it returns false and ErrPaused
+// until Resume.
+//
+// The network protocols supporting it (e.g.,
+// RTSP) are paused on the server side. TCP
+// connections stay open while paused, but the
+// packets of UDP streams may be lost.
+//
+// Pause, Resume and Paused change and read the
+// reading state of the media without locking,
+// so they must be called on the goroutine
+// calling ReadPacket. The packet prefetching is
+// stopped while paused and doesn't access it.
+func (media *Media) Pause() error {
+	if media.paused {
+		return nil
+	}
+
+	media.stopReadAhead(false)
+	code := C.av_read_pause(media.ctx)
+
+	if code < 0 && code != -C.ENOSYS {
+		return newAVError(code,
+			"couldn't pause the media")
+	}
+
+	media.paused = true
+
+	return nil
+}
+
+// Resume resumes reading the media
+// container paused with Pause. It must be
+// called on the goroutine reading the media.
+func (media *Media) Resume() error {
+	if !media.paused {
+		return nil
+	}
+
+	code := C.av_read_play(media.ctx)
+
+	if code < 0 && code != -C.ENOSYS {
+		return newAVError(code,
+			"couldn't resume the media")
+	}
+
+	media.paused = false
+	media.startReadAhead()
+
+	return nil
+}
+
+// Paused returns true if reading
+// the media container is paused.
+func (media *Media) Paused() bool {
+	return media.paused
+}
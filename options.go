@@ -0,0 +1,205 @@
+package reisen
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Options holds the parameters
+// used to open a media container.
+type Options struct {
+	// ReadAheadPackets is the number of packets
+	// prefetched from the media container on a
+	// background goroutine after the media is
+	// opened for decoding, so that ReadPacket
+	// rarely blocks on I/O.
+	//
+	// The methods of the media reading the state
+	// of the container changed by the demuxer
+	// (FileSize, Seekable, Metadata, BestVideoStream,
+	// BestAudioStream, AttachedPictures and
+	// SelectStreams) wait for the packet being
+	// read by the goroutine. The packets prefetched
+	// before SelectStreams is called are still
+	// returned by ReadPacket.
+	//
+	// Zero disables prefetching.
+	ReadAheadPackets int
+
+	// InputFormat is the short name of the demuxer
+	// to open the media with (e.g., "rawvideo")
+	// instead of probing the format of the media.
+	InputFormat string
+	// InputPixelFormat is the pixel format of the
+	// video frames (e.g., "rgb24" or "yuv420p") for
+	// the demuxers of headerless raw video.
+	InputPixelFormat string
+	// InputVideoSize is the size of the video
+	// frames (e.g., "1920x1080") for the demuxers
+	// of headerless raw video.
+	InputVideoSize string
+	// InputFrameRate is the frame rate of the video
+	// (e.g., "30" or "30000/1001") for the demuxers
+	// of headerless raw video.
+	InputFrameRate string
+
+	// WantedStreams are the indices of the streams
+	// to read the packets of. The packets of the
+	// other streams are discarded by the demuxer,
+	// which saves the demuxing time for the media
+	// with many streams (see Media.SelectStreams).
+	//
+	// All the streams are read if it's empty.
+	WantedStreams []int
+
+	// MaxFrames is the maximum number of frames
+	// decoded from all the streams of the media.
+	// Decoding fails with ErrDecodeLimit once it's
+	// exceeded, which guards against untrusted
+	// media with an enormous number of frames.
+	//
+	// Zero means no limit.
+	MaxFrames int64
+	// MaxDecodeDuration is the maximum total time
+	// spent on decoding the frames of all the
+	// streams of the media. Decoding fails
+	// with ErrDecodeLimit once it's exceeded.
+	//
+	// Zero means no limit.
+	MaxDecodeDuration time.Duration
+
+	// Headers are the HTTP headers sent
+	// with the requests of HTTP streams.
+	Headers map[string]string
+	// HTTPSeekable forces seeking HTTP(S) sources
+	// with range requests even if the server doesn't
+	// advertise the support for them. By default,
+	// the seeking is available if the server
+	// accepts range requests (see Media.Seekable).
+	HTTPSeekable bool
+	// Username is the user name to authenticate
+	// the stream with (e.g., for RTSP cameras).
+	Username string
+	// Password is the password to
+	// authenticate the stream with.
+	Password string
+
+	// ForceOpaque makes the RGBA video frames opaque.
+	//
+	// By default, the alpha of the sources with
+	// an alpha channel (e.g., yuva420p VP9 or RGBA
+	// PNG sequences) is preserved in the RGBA output,
+	// and the frames of the sources without one are
+	// opaque (the alpha is 255).
+	ForceOpaque bool
+
+	// OpenRetries is the number of times opening
+	// the media is retried after a transient failure
+	// (e.g., a network timeout, a refused or reset
+	// connection or an HTTP server error). The other
+	// failures, e.g., a missing file or invalid data,
+	// are returned right away.
+	OpenRetries int
+	// OpenRetryBackoff is the delay before the first
+	// retry, it's doubled after each attempt
+	// up to 30 seconds.
+	OpenRetryBackoff time.Duration
+}
+
+const (
+	// defaultOpenRetryBackoff is the delay
+	// before the first retry to open the media
+	// if it's not set in the options.
+	defaultOpenRetryBackoff = 500 * time.Millisecond
+	// maxOpenRetryBackoff is the maximum delay
+	// between the retries to open the media.
+	maxOpenRetryBackoff = 30 * time.Second
+)
+
+// demuxerOptions returns the options
+// to pass to the demuxer on opening.
+func (opts *Options) demuxerOptions() map[string]string {
+	demuxerOpts := map[string]string{}
+
+	if opts.InputPixelFormat != "" {
+		demuxerOpts["pixel_format"] = opts.InputPixelFormat
+	}
+
+	if opts.InputVideoSize != "" {
+		demuxerOpts["video_size"] = opts.InputVideoSize
+	}
+
+	if opts.InputFrameRate != "" {
+		demuxerOpts["framerate"] = opts.InputFrameRate
+	}
+
+	if opts.HTTPSeekable {
+		demuxerOpts["seekable"] = "1"
+	}
+
+	if len(opts.Headers) > 0 {
+		keys := make([]string, 0, len(opts.Headers))
+
+		for key := range opts.Headers {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		var headers strings.Builder
+
+		for _, key := range keys {
+			headers.WriteString(key)
+			headers.WriteString(": ")
+			headers.WriteString(opts.Headers[key])
+			headers.WriteString("\r\n")
+		}
+
+		demuxerOpts["headers"] = headers.String()
+	}
+
+	return demuxerOpts
+}
+
+// inputURL returns the URL of the media to open
+// with the credentials from the options. They
+// are embedded into the URL since that's how
+// libAV protocols (e.g., RTSP) receive them.
+//
+// The result must not be logged or
+// put into error messages.
+func (opts *Options) inputURL(filename string) string {
+	if opts.Username == "" && opts.Password == "" {
+		return filename
+	}
+
+	u, err := url.Parse(filename)
+
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil {
+		return filename
+	}
+
+	u.User = url.UserPassword(opts.Username, opts.Password)
+
+	return u.String()
+}
+
+// checkDecodeLimits returns an error if the
+// decoding limits set in the options are exceeded.
+func (media *Media) checkDecodeLimits() error {
+	if media.options.MaxFrames > 0 &&
+		media.framesDecoded >= media.options.MaxFrames {
+		return fmt.Errorf("%w: %d decoded frames",
+			ErrDecodeLimit, media.options.MaxFrames)
+	}
+
+	if media.options.MaxDecodeDuration > 0 &&
+		media.decodeTime >= media.options.MaxDecodeDuration {
+		return fmt.Errorf("%w: %v of decoding time",
+			ErrDecodeLimit, media.options.MaxDecodeDuration)
+	}
+
+	return nil
+}
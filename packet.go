@@ -23,6 +23,7 @@ type Packet struct {
 	duration    int64
 	size        int
 	flags       int
+	boundary    bool
 }
 
 // StreamIndex returns the index of the
@@ -47,6 +48,13 @@ func (pkt *Packet) Data() []byte {
 	return buf
 }
 
+// SegmentBoundary returns true if the packet
+// starts a new segment when the segmentation
+// of the media is enabled.
+func (pkt *Packet) SegmentBoundary() bool {
+	return pkt.boundary
+}
+
 // Returns the size of the
 // packet data.
 func (pkt *Packet) Size() int {
@@ -0,0 +1,94 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import (
+	"fmt"
+	"time"
+)
+
+// PacketStats holds the statistics of
+// the packets of a media stream.
+type PacketStats struct {
+	// Packets is the number of
+	// the sampled packets.
+	Packets int
+	// AverageSize is the mean size
+	// of a packet in bytes.
+	AverageSize float64
+	// MaxSize is the size of the
+	// largest packet in bytes.
+	MaxSize int
+	// AverageDuration is the mean
+	// duration of a packet.
+	AverageDuration time.Duration
+	// MaxDuration is the duration
+	// of the longest packet.
+	MaxDuration time.Duration
+}
+
+// PacketStats reads the specified number of packets
+// of the stream from the beginning of the media
+// without decoding them and returns the statistics
+// of their sizes and durations.
+func (stream *baseStream) PacketStats(sample int) (*PacketStats, error) {
+	if sample <= 0 {
+		return nil, fmt.Errorf(
+			"the number of packets to sample must be positive")
+	}
+
+	tbNum, tbDen := stream.TimeBase()
+	tb := float64(tbNum) / float64(tbDen)
+	stats := &PacketStats{}
+	var totalSize, totalDuration, maxDuration int64
+
+	err := stream.media.scanPackets(func(packet *C.AVPacket) bool {
+		if packet.stream_index != stream.inner.index {
+			return true
+		}
+
+		stats.Packets++
+		size := int(packet.size)
+		duration := int64(packet.duration)
+		totalSize += int64(size)
+		totalDuration += duration
+
+		if size > stats.MaxSize {
+			stats.MaxSize = size
+		}
+
+		if duration > maxDuration {
+			maxDuration = duration
+		}
+
+		return stats.Packets < sample
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Packets <= 0 {
+		return nil, fmt.Errorf(
+			"no packets found for the stream")
+	}
+
+	stats.AverageSize = float64(totalSize) / float64(stats.Packets)
+	stats.AverageDuration, err = time.ParseDuration(fmt.Sprintf(
+		"%fs", float64(totalDuration)*tb/float64(stats.Packets)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats.MaxDuration, err = time.ParseDuration(
+		fmt.Sprintf("%fs", float64(maxDuration)*tb))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,105 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// skipSamplesSize is the size of the
+// AV_PKT_DATA_SKIP_SAMPLES side data: the
+// numbers of the samples to skip at the start
+// and at the end (32-bit little-endian each)
+// followed by the reasons for skipping them.
+const skipSamplesSize = 10
+
+// parseSkipSamples returns the numbers of the samples
+// to skip at the start and at the end of the audio
+// from the AV_PKT_DATA_SKIP_SAMPLES side data.
+func parseSkipSamples(data *C.uint8_t, size C.size_t) (int, int, bool) {
+	if data == nil || size < skipSamplesSize {
+		return 0, 0, false
+	}
+
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(data)), size)
+
+	return int(binary.LittleEndian.Uint32(buf[0:4])),
+		int(binary.LittleEndian.Uint32(buf[4:8])), true
+}
+
+// recordSkipSamples remembers the encoder delay and
+// padding declared in the side data of the packet.
+func (audio *AudioStream) recordSkipSamples(packet *C.AVPacket) {
+	if packet == nil || packet.stream_index != audio.inner.index {
+		return
+	}
+
+	var size C.size_t
+	data := C.av_packet_get_side_data(packet,
+		C.AV_PKT_DATA_SKIP_SAMPLES, &size)
+	start, end, ok := parseSkipSamples(data, size)
+
+	if !ok {
+		return
+	}
+
+	if start > 0 && audio.packetDelay == 0 {
+		audio.packetDelay = start
+	}
+
+	if end > 0 {
+		audio.packetPadding = end
+	}
+}
+
+// EncoderDelay returns the number of the priming
+// samples inserted by the encoder at the start
+// of the audio which should be skipped for
+// gapless playback.
+//
+// It's taken from the codec parameters or the
+// skip samples side data of the stream. If the
+// delay is only declared in the side data of
+// the packets, it's known after the first
+// packets are decoded.
+func (audio *AudioStream) EncoderDelay() int {
+	if audio.codecParams.initial_padding > 0 {
+		return int(audio.codecParams.initial_padding)
+	}
+
+	var size C.size_t
+	data := C.av_stream_get_side_data(audio.inner,
+		C.AV_PKT_DATA_SKIP_SAMPLES, &size)
+
+	if start, _, ok := parseSkipSamples(data, size); ok && start > 0 {
+		return start
+	}
+
+	return audio.packetDelay
+}
+
+// EncoderPadding returns the number of the samples
+// appended by the encoder at the end of the audio
+// which should be trimmed for gapless playback.
+//
+// If the padding is only declared in the side
+// data of the packets, it's known after the
+// last packet is decoded.
+func (audio *AudioStream) EncoderPadding() int {
+	if audio.codecParams.trailing_padding > 0 {
+		return int(audio.codecParams.trailing_padding)
+	}
+
+	var size C.size_t
+	data := C.av_stream_get_side_data(audio.inner,
+		C.AV_PKT_DATA_SKIP_SAMPLES, &size)
+
+	if _, end, ok := parseSkipSamples(data, size); ok && end > 0 {
+		return end
+	}
+
+	return audio.packetPadding
+}
@@ -0,0 +1,410 @@
+package reisen
+
+// #cgo pkg-config: libavcodec libavutil libswscale
+// #include <libavcodec/avcodec.h>
+// #include <libavutil/imgutils.h>
+// #include <libswscale/swscale.h>
+import "C"
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// decodeJob is a packet of
+// the stream to decode.
+type decodeJob struct {
+	seq    int
+	packet *C.AVPacket
+}
+
+// decodeResult is the frame decoded
+// from the packet of the job.
+type decodeResult struct {
+	seq   int
+	frame *VideoFrame
+	err   error
+}
+
+// decodeWorker is an independent decoder
+// of the frames of an intra-only stream.
+type decodeWorker struct {
+	video     *VideoStream
+	codecCtx  *C.AVCodecContext
+	frame     *C.AVFrame
+	rgbaFrame *C.AVFrame
+	bufSize   C.int
+	threads   int
+	swsCtx    *C.struct_SwsContext
+	srcWidth  C.int
+	srcHeight C.int
+	srcFormat C.enum_AVPixelFormat
+}
+
+// IntraOnly returns true if every frame of the
+// stream is coded independently of the others
+// (e.g., MJPEG), so the frames can be decoded
+// in parallel.
+func (video *VideoStream) IntraOnly() bool {
+	desc := C.avcodec_descriptor_get(video.codecParams.codec_id)
+
+	return desc != nil && desc.props&C.AV_CODEC_PROP_INTRA_ONLY != 0
+}
+
+// DecodeParallel decodes all the remaining frames of
+// the intra-only video stream (e.g., MJPEG) on the
+// specified number of goroutines, each one with its
+// own decoder, and passes them to the handler in
+// the order of the packets until it returns an error.
+//
+// The stream must be opened, the output size and
+// format of the frames are the ones the stream was
+// opened with. The packets of the other streams
+// read on the way are dropped.
+//
+// Every worker takes a thread of the budget set with
+// SetMaxTotalThreads, so fewer workers are started if
+// the budget runs out, and ErrNoThreads is returned
+// if there are no threads left for any of them.
+func (video *VideoStream) DecodeParallel(workers int, handler func(*VideoFrame) error) error {
+	if !video.opened {
+		return ErrStreamNotOpened
+	}
+
+	if !video.IntraOnly() {
+		return fmt.Errorf(
+			"the %s codec is not intra-only", video.CodecName())
+	}
+
+	if workers < 1 {
+		return fmt.Errorf(
+			"the number of workers must be positive")
+	}
+
+	decoders := make([]*decodeWorker, 0, workers)
+
+	defer func() {
+		for _, decoder := range decoders {
+			decoder.free()
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		decoder, err := video.newDecodeWorker()
+
+		if err == ErrNoThreads && len(decoders) > 0 {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		decoders = append(decoders, decoder)
+	}
+
+	jobs := make(chan decodeJob, len(decoders))
+	results := make(chan decodeResult, len(decoders))
+	done := make(chan struct{})
+	var readErr error
+	var readWg, workWg sync.WaitGroup
+
+	readWg.Add(1)
+	go func() {
+		defer readWg.Done()
+		defer close(jobs)
+		readErr = video.readParallelJobs(jobs, done)
+	}()
+
+	for _, decoder := range decoders {
+		workWg.Add(1)
+		go func(decoder *decodeWorker) {
+			defer workWg.Done()
+			decoder.run(jobs, results, done)
+		}(decoder)
+	}
+
+	go func() {
+		workWg.Wait()
+		close(results)
+	}()
+
+	pending := map[int]decodeResult{}
+	next := 0
+	var err error
+
+	for result := range results {
+		pending[result.seq] = result
+
+		for err == nil {
+			result, ok := pending[next]
+
+			if !ok {
+				break
+			}
+
+			delete(pending, next)
+			next++
+
+			if result.err != nil {
+				err = result.err
+			} else if result.frame != nil {
+				err = handler(result.frame)
+			}
+
+			if err != nil {
+				close(done)
+			}
+		}
+	}
+
+	readWg.Wait()
+
+	// Free the packets left
+	// after the cancellation.
+	for job := range jobs {
+		C.av_packet_free(&job.packet)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return readErr
+}
+
+// readParallelJobs reads the packets of the stream
+// and sends them to the decoding workers until the
+// end of the media or the cancellation.
+func (video *VideoStream) readParallelJobs(jobs chan<- decodeJob, done <-chan struct{}) error {
+	for seq := 0; ; {
+		pkt, gotPacket, err := video.media.ReadPacket()
+
+		if err != nil && !isEndOfFile(err) {
+			return err
+		}
+
+		if !gotPacket {
+			return nil
+		}
+
+		if pkt == nil || pkt.StreamIndex() != video.Index() {
+			continue
+		}
+
+		src := video.media.packet
+
+		if video.filterCtx != nil {
+			src = video.filterOutPacket
+		}
+
+		packet := C.av_packet_alloc()
+
+		if packet == nil {
+			return fmt.Errorf(
+				"couldn't allocate a new packet")
+		}
+
+		status := C.av_packet_ref(packet, src)
+
+		if status < 0 {
+			C.av_packet_free(&packet)
+
+			return newAVError(status,
+				"couldn't reference the packet")
+		}
+
+		select {
+		case jobs <- decodeJob{seq: seq, packet: packet}:
+			seq++
+
+		case <-done:
+			C.av_packet_free(&packet)
+			return nil
+		}
+	}
+}
+
+// newDecodeWorker creates a new decoder
+// for the frames of the video stream.
+func (video *VideoStream) newDecodeWorker() (*decodeWorker, error) {
+	threadCount, err := threads.acquireOne()
+
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := &decodeWorker{
+		video:    video,
+		threads:  threadCount,
+		codecCtx: C.avcodec_alloc_context3(video.codec),
+	}
+
+	if decoder.codecCtx == nil {
+		decoder.free()
+
+		return nil, fmt.Errorf("couldn't open a codec context")
+	}
+
+	status := C.avcodec_parameters_to_context(
+		decoder.codecCtx, video.codecParams)
+
+	if status < 0 {
+		decoder.free()
+
+		return nil, newAVError(status,
+			"couldn't send codec parameters to the context")
+	}
+
+	decoder.codecCtx.thread_count = 1
+	status = C.avcodec_open2(decoder.codecCtx, video.codec, nil)
+
+	if status < 0 {
+		decoder.free()
+
+		return nil, newAVError(status,
+			"couldn't open the codec context")
+	}
+
+	decoder.frame = C.av_frame_alloc()
+	decoder.rgbaFrame = C.av_frame_alloc()
+
+	if decoder.frame == nil || decoder.rgbaFrame == nil {
+		decoder.free()
+
+		return nil, fmt.Errorf(
+			"couldn't allocate a new frame")
+	}
+
+	align := video.outAlign
+
+	if align <= 0 {
+		align = 1
+	}
+
+	// The image is laid out like the output
+	// buffer of the stream, including the
+	// row alignment.
+	status = C.av_image_alloc(&decoder.rgbaFrame.data[0],
+		&decoder.rgbaFrame.linesize[0], C.int(video.dstWidth),
+		C.int(video.dstHeight), video.outFormat, C.int(align))
+
+	if status < 0 {
+		decoder.free()
+
+		return nil, newAVError(status,
+			"couldn't allocate the image")
+	}
+
+	decoder.bufSize = status
+
+	return decoder, nil
+}
+
+// run decodes the packets of the jobs
+// until there are no more jobs.
+func (decoder *decodeWorker) run(jobs <-chan decodeJob, results chan<- decodeResult, done <-chan struct{}) {
+	for job := range jobs {
+		frame, err := decoder.decode(job.packet)
+		C.av_packet_free(&job.packet)
+
+		select {
+		case results <- decodeResult{seq: job.seq, frame: frame, err: err}:
+		case <-done:
+		}
+	}
+}
+
+// decode decodes and scales the frame of the packet.
+func (decoder *decodeWorker) decode(packet *C.AVPacket) (*VideoFrame, error) {
+	video := decoder.video
+	status := C.avcodec_send_packet(decoder.codecCtx, packet)
+
+	if status < 0 {
+		return nil, newAVError(status,
+			"couldn't send the packet to the codec context")
+	}
+
+	status = C.avcodec_receive_frame(decoder.codecCtx, decoder.frame)
+
+	if status < 0 {
+		if status == C.int(ErrorAgain) {
+			return nil, nil
+		}
+
+		return nil, newAVError(status,
+			"couldn't receive the frame from the codec context")
+	}
+
+	defer C.av_frame_unref(decoder.frame)
+	srcFormat := C.enum_AVPixelFormat(decoder.frame.format)
+
+	if decoder.swsCtx == nil || decoder.frame.width != decoder.srcWidth ||
+		decoder.frame.height != decoder.srcHeight ||
+		srcFormat != decoder.srcFormat {
+		C.sws_freeContext(decoder.swsCtx)
+		decoder.swsCtx = C.sws_getContext(decoder.frame.width,
+			decoder.frame.height, srcFormat,
+			C.int(video.dstWidth), C.int(video.dstHeight),
+			video.outFormat, C.int(video.interpolation), nil, nil, nil)
+
+		if decoder.swsCtx == nil {
+			return nil, fmt.Errorf(
+				"couldn't create an SWS context")
+		}
+
+		decoder.srcWidth = decoder.frame.width
+		decoder.srcHeight = decoder.frame.height
+		decoder.srcFormat = srcFormat
+	}
+
+	C.sws_scale(decoder.swsCtx, &decoder.frame.data[0],
+		&decoder.frame.linesize[0], 0,
+		decoder.frame.height,
+		&decoder.rgbaFrame.data[0],
+		&decoder.rgbaFrame.linesize[0])
+
+	data := C.GoBytes(unsafe.
+		Pointer(decoder.rgbaFrame.data[0]),
+		decoder.bufSize)
+
+	if video.media.options.ForceOpaque &&
+		video.outFormat == C.AV_PIX_FMT_RGBA && hasAlpha(srcFormat) {
+		fillOpaque(data)
+	}
+
+	frame := newVideoFrame(video, int64(decoder.frame.pts),
+		int(decoder.frame.coded_picture_number),
+		int(decoder.frame.display_picture_number),
+		video.dstWidth, video.dstHeight, video.outFormat,
+		int(decoder.rgbaFrame.linesize[0]), data)
+	frame.repeatPict = int(decoder.frame.repeat_pict)
+	frame.wallClock, frame.hasWallClock = video.media.
+		wallClock(video, int64(decoder.frame.pts))
+
+	return frame, nil
+}
+
+// free frees the memory of the decoder.
+func (decoder *decodeWorker) free() {
+	if decoder.rgbaFrame != nil {
+		C.av_freep(unsafe.Pointer(&decoder.rgbaFrame.data[0]))
+		C.av_frame_free(&decoder.rgbaFrame)
+	}
+
+	if decoder.frame != nil {
+		C.av_frame_free(&decoder.frame)
+	}
+
+	if decoder.swsCtx != nil {
+		C.sws_freeContext(decoder.swsCtx)
+		decoder.swsCtx = nil
+	}
+
+	if decoder.codecCtx != nil {
+		C.avcodec_free_context(&decoder.codecCtx)
+	}
+
+	threads.release(decoder.threads)
+	decoder.threads = 0
+}
@@ -0,0 +1,41 @@
+package reisen
+
+// #cgo pkg-config: libavcodec
+// #include <libavcodec/avcodec.h>
+import "C"
+import "fmt"
+
+// PeekPTS returns the presentation timestamps (in time
+// base units) of the next n packets of the stream in
+// the decoding order without decoding them, e.g., to
+// build a seek index.
+//
+// The packets read on the way are kept and returned
+// by the following reads, so the reading position of
+// the media doesn't change. The timestamp is
+// math.MinInt64 if the packet has none. Fewer than n
+// timestamps are returned if the media ends, no packet
+// is available yet or too many packets are pending.
+func (video *VideoStream) PeekPTS(n int) ([]int64, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf(
+			"invalid number of packets %d", n)
+	}
+
+	if video.media.packet == nil {
+		return nil, fmt.Errorf(
+			"the media is not opened for decoding")
+	}
+
+	timestamps := make([]int64, 0, n)
+
+	video.media.peekPackets(func(packet *C.AVPacket) bool {
+		if packet.stream_index == video.inner.index {
+			timestamps = append(timestamps, int64(packet.pts))
+		}
+
+		return len(timestamps) < n
+	})
+
+	return timestamps, nil
+}
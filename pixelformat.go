@@ -0,0 +1,23 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/pixfmt.h>
+import "C"
+
+// PixelFormat is a pixel format of
+// the decoded video frames.
+type PixelFormat int
+
+const (
+	PixelFormatRGBA   PixelFormat = C.AV_PIX_FMT_RGBA
+	PixelFormatBGRA   PixelFormat = C.AV_PIX_FMT_BGRA
+	PixelFormatRGB24  PixelFormat = C.AV_PIX_FMT_RGB24
+	PixelFormatBGR24  PixelFormat = C.AV_PIX_FMT_BGR24
+	PixelFormatGray8  PixelFormat = C.AV_PIX_FMT_GRAY8
+	PixelFormatGray16 PixelFormat = C.AV_PIX_FMT_GRAY16BE
+)
+
+// String returns the name of the pixel format.
+func (pixFmt PixelFormat) String() string {
+	return pixelFormatName(C.enum_AVPixelFormat(pixFmt))
+}
@@ -2,6 +2,11 @@ package reisen
 
 import "C"
 
+// hwDevicePreference is the list of the hardware
+// devices to decode video on in the order of
+// preference for the platform.
+var hwDevicePreference = []string{"cuda", "vaapi", "vdpau"}
+
 func bufferSize(maxBufferSize C.int) C.ulong {
 	var byteSize C.ulong = 8
 	return C.ulong(maxBufferSize) * byteSize
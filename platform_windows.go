@@ -2,6 +2,11 @@ package reisen
 
 import "C"
 
+// hwDevicePreference is the list of the hardware
+// devices to decode video on in the order of
+// preference for the platform.
+var hwDevicePreference = []string{"d3d11va", "dxva2", "cuda"}
+
 func bufferSize(maxBufferSize C.int) C.ulonglong {
 	var byteSize C.ulonglong = 8
 	return C.ulonglong(maxBufferSize) * byteSize
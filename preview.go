@@ -0,0 +1,75 @@
+package reisen
+
+import "strings"
+
+// previewKeywords are the words in the handler
+// name or the title of a track denoting
+// a preview (thumbnail) track.
+var previewKeywords = []string{"preview", "thumb"}
+
+// PreviewTrack returns the low-resolution preview
+// (thumbnail) video track of the media if there's one.
+//
+// A preview track is a video stream other than the
+// cover art which has a lower resolution than the
+// main video stream. The streams named as previews
+// or thumbnails in their metadata are preferred.
+// If there's no such a track, keyframes of the main
+// video stream should be decoded instead.
+func (media *Media) PreviewTrack() (*VideoStream, bool) {
+	videoStreams := []*VideoStream{}
+
+	for _, video := range media.VideoStreams() {
+		if !video.IsCoverArt() {
+			videoStreams = append(videoStreams, video)
+		}
+	}
+
+	if len(videoStreams) < 2 {
+		return nil, false
+	}
+
+	var main, preview *VideoStream
+
+	for _, video := range videoStreams {
+		if main == nil || video.Width()*video.Height() >
+			main.Width()*main.Height() {
+			main = video
+		}
+	}
+
+	for _, video := range videoStreams {
+		if video == main || video.Width()*video.Height() >=
+			main.Width()*main.Height() {
+			continue
+		}
+
+		if video.namedPreview() {
+			return video, true
+		}
+
+		if preview == nil || video.Width()*video.Height() <
+			preview.Width()*preview.Height() {
+			preview = video
+		}
+	}
+
+	return preview, preview != nil
+}
+
+// namedPreview returns true if the metadata of the
+// video stream names it as a preview track.
+func (video *VideoStream) namedPreview() bool {
+	for _, key := range []string{"handler_name", "title"} {
+		value := strings.ToLower(
+			dictionaryValue(video.inner.metadata, key))
+
+		for _, keyword := range previewKeywords {
+			if strings.Contains(value, keyword) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
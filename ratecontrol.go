@@ -0,0 +1,56 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import "unsafe"
+
+// cpbProperties returns the coded picture buffer
+// properties declared in the stream (e.g., the HRD
+// parameters of the H.264 SPS) or nil.
+func (video *VideoStream) cpbProperties() *C.AVCPBProperties {
+	var size C.size_t
+	data := C.av_stream_get_side_data(video.inner,
+		C.AV_PKT_DATA_CPB_PROPERTIES, &size)
+
+	if data == nil || uintptr(size) < unsafe.Sizeof(C.AVCPBProperties{}) {
+		return nil
+	}
+
+	return (*C.AVCPBProperties)(unsafe.Pointer(data))
+}
+
+// MaxBitRate returns the maximum bit rate of the
+// stream (in bps) declared for the rate control
+// (VBV/HRD) or 0 if it's unknown.
+func (video *VideoStream) MaxBitRate() int64 {
+	props := video.cpbProperties()
+
+	if props != nil && props.max_bitrate > 0 {
+		return int64(props.max_bitrate)
+	}
+
+	if video.codecCtx != nil && video.codecCtx.rc_max_rate > 0 {
+		return int64(video.codecCtx.rc_max_rate)
+	}
+
+	return 0
+}
+
+// RCBufferSize returns the size of the rate control
+// (VBV/HRD) buffer of the stream in bits or 0 if
+// it's unknown.
+func (video *VideoStream) RCBufferSize() int64 {
+	props := video.cpbProperties()
+
+	if props != nil && props.buffer_size > 0 {
+		return int64(props.buffer_size)
+	}
+
+	if video.codecCtx != nil && video.codecCtx.rc_buffer_size > 0 {
+		return int64(video.codecCtx.rc_buffer_size)
+	}
+
+	return 0
+}
@@ -0,0 +1,279 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+
+// maxPeekedPackets is the maximum number of packets
+// kept in the pending queue while peeking, so a stream
+// rarely present in the container doesn't make the
+// peeking buffer the whole media.
+const maxPeekedPackets = 4096
+
+// prefetchedPacket is a packet read
+// from the media container in advance.
+type prefetchedPacket struct {
+	packet *C.AVPacket
+	status C.int
+}
+
+// readAhead holds the state of the goroutine
+// prefetching packets from the media container.
+type readAhead struct {
+	packets chan prefetchedPacket
+	stop    chan struct{}
+	done    chan struct{}
+	// The status the goroutine finished with,
+	// it's set before the packets are closed.
+	last C.int
+}
+
+// startReadAhead launches the goroutine
+// prefetching packets from the media
+// container if it's enabled in the options.
+func (media *Media) startReadAhead() {
+	if media.options.ReadAheadPackets <= 0 || media.paused ||
+		media.packet == nil || media.prefetch != nil {
+		return
+	}
+
+	prefetch := &readAhead{
+		packets: make(chan prefetchedPacket,
+			media.options.ReadAheadPackets),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		last: C.int(ErrorEndOfFile),
+	}
+
+	media.prefetch = prefetch
+	go media.prefetchPackets(prefetch)
+}
+
+// prefetchPackets reads packets from the media
+// container until the end of the media is reached
+// or the prefetching is stopped.
+func (media *Media) prefetchPackets(prefetch *readAhead) {
+	defer close(prefetch.done)
+	defer close(prefetch.packets)
+
+	for {
+		packet := C.av_packet_alloc()
+
+		if packet == nil {
+			prefetch.last = C.int(ErrorInvalidValue)
+			return
+		}
+
+		media.ctxMu.Lock()
+		status := C.av_read_frame(media.ctx, packet)
+		media.ctxMu.Unlock()
+
+		if status < 0 {
+			C.av_packet_free(&packet)
+		}
+
+		select {
+		case prefetch.packets <- prefetchedPacket{
+			packet: packet,
+			status: status,
+		}:
+
+		case <-prefetch.stop:
+			if packet != nil {
+				C.av_packet_free(&packet)
+			}
+
+			return
+		}
+
+		if status < 0 && status != C.int(ErrorAgain) {
+			prefetch.last = status
+			return
+		}
+	}
+}
+
+// restartReadAhead restarts the goroutine prefetching
+// packets after it's been stopped by an interruption,
+// dropping the results of the interrupted reads.
+func (media *Media) restartReadAhead() {
+	if media.prefetch == nil {
+		media.startReadAhead()
+		return
+	}
+
+	media.stopReadAhead(false)
+	pending := media.pending[:0]
+
+	for _, result := range media.pending {
+		if result.status != C.AVERROR_EXIT {
+			pending = append(pending, result)
+		}
+	}
+
+	media.pending = pending
+	media.startReadAhead()
+}
+
+// stopReadAhead stops the goroutine prefetching
+// packets. The packets prefetched so far are kept
+// in the pending queue unless discard is set.
+func (media *Media) stopReadAhead(discard bool) {
+	if media.prefetch != nil {
+		close(media.prefetch.stop)
+		<-media.prefetch.done
+
+		for result := range media.prefetch.packets {
+			media.pending = append(media.pending, result)
+		}
+
+		media.prefetch = nil
+	}
+
+	if discard {
+		for _, result := range media.pending {
+			if result.packet != nil {
+				C.av_packet_free(&result.packet)
+			}
+		}
+
+		media.pending = nil
+	}
+}
+
+// readFrame reads the next packet of the media
+// container into the media packet, either from
+// the prefetched packets or directly.
+func (media *Media) readFrame() C.int {
+	C.av_packet_unref(media.packet)
+
+	var result prefetchedPacket
+
+	switch {
+	case len(media.pending) > 0:
+		result = media.pending[0]
+		media.pending = media.pending[1:]
+
+	case media.paused:
+		return C.int(ErrorAgain)
+
+	case media.prefetch != nil:
+		var ok bool
+		result, ok = <-media.prefetch.packets
+
+		// The reads fail the same way after
+		// the goroutine finishes, so an
+		// interruption isn't taken for
+		// the end of the media.
+		if !ok {
+			return media.prefetch.last
+		}
+
+	default:
+		return C.av_read_frame(media.ctx, media.packet)
+	}
+
+	if result.packet != nil {
+		C.av_packet_move_ref(media.packet, result.packet)
+		C.av_packet_free(&result.packet)
+	}
+
+	return result.status
+}
+
+// fetchPacket reads the next packet of the media
+// container into a newly allocated packet, either
+// from the read-ahead goroutine or directly.
+func (media *Media) fetchPacket() prefetchedPacket {
+	if media.prefetch != nil {
+		result, ok := <-media.prefetch.packets
+
+		if !ok {
+			return prefetchedPacket{
+				status: media.prefetch.last,
+			}
+		}
+
+		return result
+	}
+
+	packet := C.av_packet_alloc()
+
+	if packet == nil {
+		return prefetchedPacket{
+			status: C.int(ErrorInvalidValue),
+		}
+	}
+
+	status := C.av_read_frame(media.ctx, packet)
+
+	if status < 0 {
+		C.av_packet_free(&packet)
+	}
+
+	return prefetchedPacket{
+		packet: packet,
+		status: status,
+	}
+}
+
+// peekPackets passes the upcoming packets of the media
+// container to the handler until it returns false or
+// the packets end. The packets are kept in the pending
+// queue, so they're read again afterwards and the
+// reading position doesn't change. The peeking also
+// stops when no packet is available yet (e.g., on a
+// network stream) or maxPeekedPackets are pending.
+func (media *Media) peekPackets(handler func(packet *C.AVPacket) bool) {
+	for _, result := range media.pending {
+		if result.status < 0 {
+			if result.status == C.int(ErrorAgain) {
+				continue
+			}
+
+			return
+		}
+
+		if !handler(result.packet) {
+			return
+		}
+	}
+
+	if media.paused {
+		return
+	}
+
+	for len(media.pending) < maxPeekedPackets {
+		result := media.fetchPacket()
+		media.pending = append(media.pending, result)
+
+		if result.status < 0 {
+			return
+		}
+
+		if !handler(result.packet) {
+			return
+		}
+	}
+}
+
+// seek rewinds the media container to the
+// specified position dropping all the
+// packets prefetched before.
+func (media *Media) seek(streamIndex C.int, timestamp int64, flags C.int) C.int {
+	media.stopReadAhead(true)
+
+	if media.segmenter != nil {
+		media.segmenter.start = noPTS
+	}
+
+	media.resetPTSUnwrap()
+	media.flushFilters()
+
+	status := C.av_seek_frame(media.ctx,
+		streamIndex, rewindPosition(timestamp), flags)
+	media.startReadAhead()
+
+	return status
+}
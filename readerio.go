@@ -0,0 +1,112 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavutil
+// #include <libavformat/avformat.h>
+// #include <libavformat/avio.h>
+// #include <libavutil/mem.h>
+// #include <stdint.h>
+//
+// #define READER_AVIO_BUFFER_SIZE 32768
+//
+// extern int readerRead(uintptr_t handle, uint8_t *buf, int size);
+// extern int64_t readerSeek(uintptr_t handle, int64_t offset, int whence);
+//
+// static int reader_read(void *opaque, uint8_t *buf, int buf_size) {
+//     return readerRead(*(uintptr_t *)opaque, buf, buf_size);
+// }
+//
+// static int64_t reader_seek(void *opaque, int64_t offset, int whence) {
+//     return readerSeek(*(uintptr_t *)opaque, offset, whence);
+// }
+//
+// static AVIOContext *new_reader_avio(uintptr_t handle, int seekable) {
+//     uintptr_t *opaque = av_malloc(sizeof(uintptr_t));
+//     uint8_t *buffer = av_malloc(READER_AVIO_BUFFER_SIZE);
+//     AVIOContext *pb;
+//
+//     if (opaque == NULL || buffer == NULL) {
+//         av_free(opaque);
+//         av_free(buffer);
+//         return NULL;
+//     }
+//
+//     *opaque = handle;
+//     pb = avio_alloc_context(buffer, READER_AVIO_BUFFER_SIZE, 0, opaque,
+//         reader_read, NULL, seekable ? reader_seek : NULL);
+//
+//     if (pb == NULL) {
+//         av_free(opaque);
+//         av_free(buffer);
+//         return NULL;
+//     }
+//
+//     if (!seekable)
+//         pb->seekable = 0;
+//
+//     return pb;
+// }
+//
+// static void free_reader_avio(AVIOContext **pb) {
+//     if (*pb == NULL)
+//         return;
+//
+//     av_freep(&(*pb)->opaque);
+//     av_freep(&(*pb)->buffer);
+//     avio_context_free(pb);
+// }
+import "C"
+import (
+	"fmt"
+	"io"
+	"runtime/cgo"
+)
+
+// NewMediaFromReader returns a new media container
+// analyzer for the media data read from the reader
+// opened with the given options (nil means the
+// defaults).
+//
+// If the reader also implements io.Seeker, the media
+// can be rewound, otherwise it's read sequentially.
+// The reader must not be used until the media is
+// closed, and the media can't be cloned.
+func NewMediaFromReader(r io.Reader, opts *Options) (*Media, error) {
+	handle := cgo.NewHandle(r)
+	_, seekable := r.(io.Seeker)
+	seekFlag := C.int(0)
+
+	if seekable {
+		seekFlag = 1
+	}
+
+	pb := C.new_reader_avio(C.uintptr_t(handle), seekFlag)
+
+	if pb == nil {
+		handle.Delete()
+
+		return nil, fmt.Errorf(
+			"couldn't create a reader I/O context")
+	}
+
+	interrupt := newInterruptState()
+	media, err := openMediaIO("", pb, func() {
+		C.free_reader_avio(&pb)
+		handle.Delete()
+	}, interrupt, opts, nil)
+
+	if err != nil {
+		freeInterruptState(interrupt)
+		return nil, err
+	}
+
+	media.readerIO = true
+
+	err = media.findStreams()
+
+	if err != nil {
+		media.Close()
+		return nil, err
+	}
+
+	return media, nil
+}
@@ -0,0 +1,84 @@
+package reisen
+
+// #cgo pkg-config: libavformat
+// #include <libavformat/avio.h>
+// #include <errno.h>
+// #include <stdint.h>
+import "C"
+import (
+	"io"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// readerRead fills the AVIO buffer
+// with the data of the Go reader.
+//
+//export readerRead
+func readerRead(handle C.uintptr_t, buf *C.uint8_t, size C.int) C.int {
+	r := cgo.Handle(handle).Value().(io.Reader)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(buf)), int(size))
+
+	for {
+		n, err := r.Read(data)
+
+		if n > 0 {
+			return C.int(n)
+		}
+
+		if err == io.EOF {
+			return C.int(ErrorEndOfFile)
+		}
+
+		if err != nil {
+			return -C.int(C.EIO)
+		}
+	}
+}
+
+// readerSeek seeks the Go reader
+// implementing io.Seeker.
+//
+//export readerSeek
+func readerSeek(handle C.uintptr_t, offset C.int64_t, whence C.int) C.int64_t {
+	seeker, ok := cgo.Handle(handle).Value().(io.Seeker)
+
+	if !ok {
+		return -C.int64_t(C.ENOSYS)
+	}
+
+	whence &^= C.AVSEEK_FORCE
+
+	// The size of the data is requested.
+	if whence == C.AVSEEK_SIZE {
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return -1
+		}
+
+		size, err := seeker.Seek(0, io.SeekEnd)
+
+		if err != nil {
+			return -1
+		}
+
+		_, err = seeker.Seek(pos, io.SeekStart)
+
+		if err != nil {
+			return -C.int64_t(C.EIO)
+		}
+
+		return C.int64_t(size)
+	}
+
+	// SEEK_SET, SEEK_CUR and SEEK_END
+	// match the io package constants.
+	pos, err := seeker.Seek(int64(offset), int(whence))
+
+	if err != nil {
+		return -C.int64_t(C.EIO)
+	}
+
+	return C.int64_t(pos)
+}
@@ -0,0 +1,103 @@
+package reisen
+
+// #cgo pkg-config: libavutil libswscale
+// #include <libavutil/frame.h>
+// #include <libswscale/swscale.h>
+//
+// static int scale_packed(struct SwsContext *ctx, AVFrame *src,
+//     uint8_t *dst, int dst_stride) {
+//     uint8_t *dst_data[4] = { dst, NULL, NULL, NULL };
+//     int dst_linesize[4] = { dst_stride, 0, 0, 0 };
+//
+//     return sws_scale(ctx, (const uint8_t * const *)src->data,
+//         src->linesize, 0, src->height, dst_data, dst_linesize);
+// }
+import "C"
+import (
+	"fmt"
+	"image"
+	"unsafe"
+)
+
+// ReadVideoFrameInto decodes the next video frame of
+// the stream and scales it directly into the pixels
+// of the specified image without intermediate copies.
+// The image must have the size the stream was opened
+// with for decoding in RGBA.
+//
+// It returns true if the image is filled with the new
+// frame and false if no frame is available for the
+// current packet or there's no more data. The frames
+// aren't put into the reorder buffer.
+func (video *VideoStream) ReadVideoFrameInto(img *image.RGBA) (bool, error) {
+	if video.outFormat != C.AV_PIX_FMT_RGBA {
+		return false, fmt.Errorf(
+			"the stream is not opened for decoding in RGBA")
+	}
+
+	if img == nil || img.Rect.Dx() != video.dstWidth ||
+		img.Rect.Dy() != video.dstHeight {
+		return false, fmt.Errorf(
+			"the image size doesn't match the decoding size %dx%d",
+			video.dstWidth, video.dstHeight)
+	}
+
+	if img.Stride < 4*video.dstWidth ||
+		len(img.Pix) < img.Stride*(video.dstHeight-1)+4*video.dstWidth {
+		return false, fmt.Errorf(
+			"the image pixel buffer is too small")
+	}
+
+	src, ok, err := video.nextSourceFrame()
+
+	if err != nil || !ok || src == nil {
+		return false, err
+	}
+
+	C.scale_packed(video.swsCtx, src,
+		(*C.uint8_t)(unsafe.Pointer(&img.Pix[0])), C.int(img.Stride))
+
+	if video.media.options.ForceOpaque &&
+		hasAlpha(C.enum_AVPixelFormat(src.format)) {
+		for y := 0; y < video.dstHeight; y++ {
+			row := img.Pix[y*img.Stride:]
+			fillOpaque(row[:4*video.dstWidth])
+		}
+	}
+
+	video.coverArtRead = video.IsCoverArt()
+
+	return true, nil
+}
+
+// ReadVideoFrameReuse decodes the next video frame of
+// the stream like ReadVideoFrame, but the returned frame
+// is backed by the internal buffer of the stream reused
+// for every frame, so no memory is allocated per frame.
+//
+// The frame and its pixels are only valid until the
+// next read from the stream, and they're neither
+// reordered nor cached.
+func (video *VideoStream) ReadVideoFrameReuse() (*VideoFrame, bool, error) {
+	return video.convertFrame(true)
+}
+
+// FrameBytes returns the pixels of the last frame
+// converted by the stream as a view of the internal
+// buffer of the stream without copying them, e.g.,
+// to upload them straight to a GPU texture. Its
+// rows are as many bytes apart as the stride
+// of the frame.
+//
+// The slice aliases the memory reused for every
+// frame: it's overwritten by the next read from
+// the stream and invalid after Close.
+func (video *VideoStream) FrameBytes() []byte {
+	if video.rgbaFrame == nil || video.rgbaFrame.data[0] == nil ||
+		video.bufSize <= 0 {
+		return nil
+	}
+
+	return unsafe.Slice((*byte)(unsafe.Pointer(
+		video.rgbaFrame.data[0])), int(video.bufSize))
+}
@@ -0,0 +1,61 @@
+package reisen
+
+import "sort"
+
+// SetReorderDepth sets the number of decoded frames
+// held by the stream to emit them in the order of
+// their presentation timestamps, so the timestamps
+// of the frames returned by ReadVideoFrame are
+// monotonic even if the decoder outputs frames
+// out of order. Zero disables reordering.
+//
+// The frames held in the buffer at the end of
+// the stream are obtained with FlushReorderBuffer.
+func (video *VideoStream) SetReorderDepth(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	video.reorderDepth = n
+}
+
+// ReorderDepth returns the number of frames
+// held by the stream for reordering.
+func (video *VideoStream) ReorderDepth() int {
+	return video.reorderDepth
+}
+
+// reorder puts the frame into the reorder buffer
+// and returns the frame with the lowest timestamp
+// once the buffer holds more frames than its depth.
+// It returns nil if the buffer isn't full yet.
+func (video *VideoStream) reorder(frame *VideoFrame) *VideoFrame {
+	i := sort.Search(len(video.reorderBuffer), func(i int) bool {
+		return video.reorderBuffer[i].pts > frame.pts
+	})
+
+	video.reorderBuffer = append(video.reorderBuffer, nil)
+	copy(video.reorderBuffer[i+1:], video.reorderBuffer[i:])
+	video.reorderBuffer[i] = frame
+
+	if len(video.reorderBuffer) <= video.reorderDepth {
+		return nil
+	}
+
+	next := video.reorderBuffer[0]
+	video.reorderBuffer[0] = nil
+	video.reorderBuffer = video.reorderBuffer[1:]
+
+	return next
+}
+
+// FlushReorderBuffer returns the frames remaining
+// in the reorder buffer in the presentation order
+// and empties the buffer. It should be called after
+// the last frame of the stream is read.
+func (video *VideoStream) FlushReorderBuffer() []*VideoFrame {
+	frames := video.reorderBuffer
+	video.reorderBuffer = nil
+
+	return frames
+}
@@ -0,0 +1,100 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+// #include <libavutil/display.h>
+import "C"
+import (
+	"math"
+	"strconv"
+	"unsafe"
+)
+
+// RotationSource denotes where the
+// rotation of a video stream comes from.
+type RotationSource int
+
+const (
+	// RotationSourceNone means the
+	// rotation isn't declared.
+	RotationSourceNone RotationSource = iota
+	// RotationSourceDisplayMatrix means the rotation
+	// comes from the display matrix side data, which
+	// is how libAV exposes the transformation matrix
+	// of the track header (tkhd) of MP4/MOV files.
+	RotationSourceDisplayMatrix
+	// RotationSourceMetadata means the rotation comes
+	// from the "rotate" metadata tag of the stream
+	// set by older encoders and demuxers.
+	RotationSourceMetadata
+)
+
+// String returns the name of the rotation source.
+func (source RotationSource) String() string {
+	switch source {
+	case RotationSourceDisplayMatrix:
+		return "display matrix"
+
+	case RotationSourceMetadata:
+		return "metadata"
+
+	default:
+		return "none"
+	}
+}
+
+// displayMatrixSize is the size of the
+// display matrix side data (3x3 int32).
+const displayMatrixSize = 36
+
+// Rotation returns the clockwise rotation in degrees
+// (in the range [0, 360)) to apply to the decoded
+// frames for displaying them upright and where the
+// rotation comes from.
+//
+// The display matrix of the stream is consulted
+// first, and the "rotate" metadata tag is used
+// if there's no display matrix.
+func (video *VideoStream) Rotation() (int, RotationSource) {
+	var size C.size_t
+	matrix := C.av_stream_get_side_data(video.inner,
+		C.AV_PKT_DATA_DISPLAYMATRIX, &size)
+
+	if matrix != nil && size >= displayMatrixSize {
+		// The rotation of the display matrix
+		// is counterclockwise.
+		degrees := -float64(C.av_display_rotation_get(
+			(*C.int32_t)(unsafe.Pointer(matrix))))
+
+		if !math.IsNaN(degrees) {
+			return normalizeRotation(degrees),
+				RotationSourceDisplayMatrix
+		}
+	}
+
+	tag := dictionaryValue(video.inner.metadata, "rotate")
+
+	if tag != "" {
+		degrees, err := strconv.ParseFloat(tag, 64)
+
+		if err == nil {
+			return normalizeRotation(degrees),
+				RotationSourceMetadata
+		}
+	}
+
+	return 0, RotationSourceNone
+}
+
+// normalizeRotation rounds the rotation to
+// whole degrees in the range [0, 360).
+func normalizeRotation(degrees float64) int {
+	rotation := int(math.Round(degrees)) % 360
+
+	if rotation < 0 {
+		rotation += 360
+	}
+
+	return rotation
+}
@@ -0,0 +1,75 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/channel_layout.h>
+// #include <libavutil/samplefmt.h>
+import "C"
+import "math/bits"
+
+// SampleFormat is a sample format of
+// the decoded audio frames.
+type SampleFormat int
+
+const (
+	SampleFormatU8           SampleFormat = C.AV_SAMPLE_FMT_U8
+	SampleFormatS16          SampleFormat = C.AV_SAMPLE_FMT_S16
+	SampleFormatS32          SampleFormat = C.AV_SAMPLE_FMT_S32
+	SampleFormatFloat        SampleFormat = C.AV_SAMPLE_FMT_FLT
+	SampleFormatDouble       SampleFormat = C.AV_SAMPLE_FMT_DBL
+	SampleFormatU8Planar     SampleFormat = C.AV_SAMPLE_FMT_U8P
+	SampleFormatS16Planar    SampleFormat = C.AV_SAMPLE_FMT_S16P
+	SampleFormatS32Planar    SampleFormat = C.AV_SAMPLE_FMT_S32P
+	SampleFormatFloatPlanar  SampleFormat = C.AV_SAMPLE_FMT_FLTP
+	SampleFormatDoublePlanar SampleFormat = C.AV_SAMPLE_FMT_DBLP
+)
+
+// String returns the name of the sample format.
+func (sampleFmt SampleFormat) String() string {
+	name := C.av_get_sample_fmt_name(
+		C.enum_AVSampleFormat(sampleFmt))
+
+	if name == nil {
+		return ""
+	}
+
+	return C.GoString(name)
+}
+
+// Planar returns 'true' if the samples of each
+// channel are stored in a separate plane, and
+// 'false' if the channels are interleaved.
+func (sampleFmt SampleFormat) Planar() bool {
+	return C.av_sample_fmt_is_planar(
+		C.enum_AVSampleFormat(sampleFmt)) != 0
+}
+
+// BytesPerSample returns the size
+// of one sample in bytes.
+func (sampleFmt SampleFormat) BytesPerSample() int {
+	return int(C.av_get_bytes_per_sample(
+		C.enum_AVSampleFormat(sampleFmt)))
+}
+
+// ChannelLayout is a bit mask of the
+// channels of the decoded audio frames.
+type ChannelLayout uint64
+
+const (
+	// ChannelLayoutSource keeps the channel
+	// layout of the source audio.
+	ChannelLayoutSource   ChannelLayout = 0
+	ChannelLayoutMono     ChannelLayout = C.AV_CH_LAYOUT_MONO
+	ChannelLayoutStereo   ChannelLayout = C.AV_CH_LAYOUT_STEREO
+	ChannelLayout2Point1  ChannelLayout = C.AV_CH_LAYOUT_2POINT1
+	ChannelLayoutSurround ChannelLayout = C.AV_CH_LAYOUT_SURROUND
+	ChannelLayoutQuad     ChannelLayout = C.AV_CH_LAYOUT_QUAD
+	ChannelLayout5Point0  ChannelLayout = C.AV_CH_LAYOUT_5POINT0
+	ChannelLayout5Point1  ChannelLayout = C.AV_CH_LAYOUT_5POINT1
+	ChannelLayout7Point1  ChannelLayout = C.AV_CH_LAYOUT_7POINT1
+)
+
+// ChannelCount returns the number
+// of channels of the layout.
+func (layout ChannelLayout) ChannelCount() int {
+	return bits.OnesCount64(uint64(layout))
+}
@@ -0,0 +1,81 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+import "C"
+import "fmt"
+
+// rewindStart rewinds the media container to its
+// beginning and resets the state of the streams,
+// so no frames decoded before are returned.
+func (media *Media) rewindStart() error {
+	start := int64(media.ctx.start_time)
+
+	if start == noPTS {
+		start = 0
+	}
+
+	status := media.seek(-1, start, C.AVSEEK_FLAG_BACKWARD)
+
+	if status < 0 {
+		return newAVError(status,
+			"couldn't rewind the media")
+	}
+
+	return media.resetStreams()
+}
+
+// scanPackets reads all the packets of the media
+// container from its beginning without decoding
+// them and passes each one to the handler until
+// it returns false. The media is rewound to the
+// beginning and the streams are reset before and
+// after the scan.
+func (media *Media) scanPackets(handler func(packet *C.AVPacket) bool) error {
+	err := media.rewindStart()
+
+	if err != nil {
+		return err
+	}
+
+	packet := C.av_packet_alloc()
+
+	if packet == nil {
+		return fmt.Errorf(
+			"couldn't allocate a new packet")
+	}
+
+	defer C.av_packet_free(&packet)
+
+	// Read-ahead is restarted by the
+	// final rewind of the media.
+	media.stopReadAhead(true)
+
+	for {
+		status := C.av_read_frame(media.ctx, packet)
+
+		// No packet available yet (e.g., on a paused
+		// network stream) is reported as an error,
+		// so the scan doesn't spin on the input.
+		if status < 0 {
+			if status == C.int(ErrorEndOfFile) {
+				break
+			}
+
+			media.rewindStart()
+
+			return newAVError(status,
+				"couldn't read a packet")
+		}
+
+		ok := handler(packet)
+		C.av_packet_unref(packet)
+
+		if !ok {
+			break
+		}
+	}
+
+	return media.rewindStart()
+}
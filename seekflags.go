@@ -0,0 +1,24 @@
+package reisen
+
+// #cgo pkg-config: libavformat
+// #include <libavformat/avformat.h>
+import "C"
+
+// SeekFlags is a bitmask of the
+// options of seeking the media.
+type SeekFlags int
+
+const (
+	// SeekBackward seeks to the nearest
+	// position before the specified one.
+	SeekBackward SeekFlags = C.AVSEEK_FLAG_BACKWARD
+	// SeekByte seeks by the byte position.
+	// Use Media.SeekByte for it.
+	SeekByte SeekFlags = C.AVSEEK_FLAG_BYTE
+	// SeekAny allows seeking to
+	// non-keyframes.
+	SeekAny SeekFlags = C.AVSEEK_FLAG_ANY
+	// SeekFrame seeks by the frame number
+	// for the formats supporting it.
+	SeekFrame SeekFlags = C.AVSEEK_FLAG_FRAME
+)
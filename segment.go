@@ -0,0 +1,79 @@
+package reisen
+
+// #cgo pkg-config: libavcodec
+// #include <libavcodec/avcodec.h>
+import "C"
+import (
+	"fmt"
+	"time"
+)
+
+// segmenter holds the state of
+// the segmentation of the media.
+type segmenter struct {
+	streamIndex int
+	target      int64
+	start       int64
+}
+
+// EnableSegmentation makes ReadPacket mark the packets
+// starting new segments: a segment boundary is the
+// first keyframe of the specified stream at least the
+// target duration after the start of the current
+// segment. The first keyframe of the stream starts
+// the first segment.
+func (media *Media) EnableSegmentation(streamIndex int, target time.Duration) error {
+	if streamIndex < 0 || streamIndex >= len(media.streams) {
+		return fmt.Errorf(
+			"stream index %d is out of range", streamIndex)
+	}
+
+	tbNum, tbDen := media.streams[streamIndex].TimeBase()
+
+	if tbNum <= 0 || tbDen <= 0 {
+		return fmt.Errorf(
+			"the time base of the stream is unknown")
+	}
+
+	media.segmenter = &segmenter{
+		streamIndex: streamIndex,
+		target: int64(target.Seconds() *
+			float64(tbDen) / float64(tbNum)),
+		start: noPTS,
+	}
+
+	return nil
+}
+
+// DisableSegmentation stops marking
+// the segment boundaries of the packets.
+func (media *Media) DisableSegmentation() {
+	media.segmenter = nil
+}
+
+// boundary returns true if the
+// packet starts a new segment.
+func (seg *segmenter) boundary(pkt *Packet) bool {
+	if pkt.streamIndex != seg.streamIndex ||
+		pkt.flags&C.AV_PKT_FLAG_KEY == 0 {
+		return false
+	}
+
+	ts := pkt.pts
+
+	if ts == noPTS {
+		ts = pkt.dts
+	}
+
+	if ts == noPTS {
+		return false
+	}
+
+	if seg.start != noPTS && ts-seg.start < seg.target {
+		return false
+	}
+
+	seg.start = ts
+
+	return true
+}
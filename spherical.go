@@ -0,0 +1,83 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+// #include <libavutil/spherical.h>
+import "C"
+import "unsafe"
+
+// SphericalProjection is a projection
+// of the 360-degree video frames.
+type SphericalProjection int
+
+const (
+	SphericalEquirectangular     SphericalProjection = C.AV_SPHERICAL_EQUIRECTANGULAR
+	SphericalCubemap             SphericalProjection = C.AV_SPHERICAL_CUBEMAP
+	SphericalEquirectangularTile SphericalProjection = C.AV_SPHERICAL_EQUIRECTANGULAR_TILE
+)
+
+// String returns the name of the projection.
+func (projection SphericalProjection) String() string {
+	name := C.av_spherical_projection_name(
+		C.enum_AVSphericalProjection(projection))
+
+	if name == nil {
+		return ""
+	}
+
+	return C.GoString(name)
+}
+
+// SphericalInfo describes the spherical
+// mapping of the 360-degree video.
+type SphericalInfo struct {
+	// Projection is the projection
+	// of the video frames.
+	Projection SphericalProjection
+	// Yaw, Pitch and Roll are the initial
+	// orientation of the view in degrees.
+	Yaw   float64
+	Pitch float64
+	Roll  float64
+	// BoundLeft, BoundTop, BoundRight and
+	// BoundBottom are the distances of the
+	// tile edges from the frame edges of the
+	// tiled equirectangular projection as
+	// 0.32 fixed-point fractions.
+	BoundLeft   uint32
+	BoundTop    uint32
+	BoundRight  uint32
+	BoundBottom uint32
+	// Padding is the number of the pixels
+	// between the faces of the cubemap.
+	Padding uint32
+}
+
+// SphericalMapping returns the spherical mapping
+// of the 360-degree video declared in the stream.
+// ok is false if the video is not spherical.
+func (video *VideoStream) SphericalMapping() (*SphericalInfo, bool) {
+	var size C.size_t
+	data := C.av_stream_get_side_data(video.inner,
+		C.AV_PKT_DATA_SPHERICAL, &size)
+
+	if data == nil || uintptr(size) < unsafe.Sizeof(C.AVSphericalMapping{}) {
+		return nil, false
+	}
+
+	mapping := (*C.AVSphericalMapping)(unsafe.Pointer(data))
+
+	// The angles are 16.16 fixed-point numbers.
+	return &SphericalInfo{
+		Projection:  SphericalProjection(mapping.projection),
+		Yaw:         float64(mapping.yaw) / (1 << 16),
+		Pitch:       float64(mapping.pitch) / (1 << 16),
+		Roll:        float64(mapping.roll) / (1 << 16),
+		BoundLeft:   uint32(mapping.bound_left),
+		BoundTop:    uint32(mapping.bound_top),
+		BoundRight:  uint32(mapping.bound_right),
+		BoundBottom: uint32(mapping.bound_bottom),
+		Padding:     uint32(mapping.padding),
+	}, true
+}
@@ -0,0 +1,22 @@
+package reisen
+
+// StreamStats holds the decoding
+// counters of a media stream.
+type StreamStats struct {
+	// FramesDecoded is the number of
+	// frames obtained from the decoder.
+	FramesDecoded int64
+	// FramesSkipped is the number of
+	// packets that produced no frame
+	// because the decoder needed more data.
+	FramesSkipped int64
+	// DecodeErrors is the number of
+	// packets the decoder failed on.
+	DecodeErrors int64
+}
+
+// Stats returns the decoding
+// counters of the stream.
+func (stream *baseStream) Stats() StreamStats {
+	return stream.stats
+}
@@ -5,6 +5,7 @@ package reisen
 // #include <libavformat/avformat.h>
 // #include <libavutil/avconfig.h>
 // #include <libavcodec/bsf.h>
+// #include <libavutil/hwcontext.h>
 import "C"
 import (
 	"fmt"
@@ -21,6 +22,8 @@ const (
 	StreamVideo StreamType = C.AVMEDIA_TYPE_VIDEO
 	// StreamAudio denotes the stream keeping audio frames.
 	StreamAudio StreamType = C.AVMEDIA_TYPE_AUDIO
+	// StreamSubtitle denotes the stream keeping subtitles.
+	StreamSubtitle StreamType = C.AVMEDIA_TYPE_SUBTITLE
 )
 
 // String returns the string representation of
@@ -33,15 +36,23 @@ func (streamType StreamType) String() string {
 	case StreamAudio:
 		return "audio"
 
+	case StreamSubtitle:
+		return "subtitle"
+
 	default:
 		return ""
 	}
 }
 
-// TODO: add an opportunity to
-// receive duration in time base units.
-
 // Stream is an abstract media data stream.
+//
+// The methods sampling the packets of the stream
+// without decoding them (MeasuredBitRate,
+// IsConstantBitRate, PacketStats and the
+// MeasuredFrameRate and AnalyzeGOP methods of
+// VideoStream) read the media from its beginning
+// and leave it rewound to the beginning with the
+// decoding state of all the streams reset.
 type Stream interface {
 	// innerStream returns the inner
 	// libAV stream of the Stream object.
@@ -63,6 +74,10 @@ type Stream interface {
 	read() (bool, error)
 	// close closes the stream for decoding.
 	close() error
+	// reset drops the decoding state of the
+	// stream buffered from the previous
+	// position after the media is seeked.
+	reset() error
 
 	// Index returns the index
 	// number of the stream.
@@ -81,9 +96,24 @@ type Stream interface {
 	// BitRate returns the stream
 	// bitrate (in bps).
 	BitRate() int64
+	// MeasuredBitRate returns the bit rate
+	// computed from the packet sizes within
+	// the time window (in bps).
+	MeasuredBitRate(time.Duration) (int64, error)
+	// IsConstantBitRate returns true if the bit
+	// rate of the specified number of packets
+	// of the stream is effectively constant.
+	IsConstantBitRate(int) (bool, error)
+	// PacketStats returns the statistics of
+	// the sizes and durations of the specified
+	// number of packets of the stream.
+	PacketStats(int) (*PacketStats, error)
 	// Duration returns the time
 	// duration of the stream
 	Duration() (time.Duration, error)
+	// DurationTimeBase returns the duration
+	// of the stream in time base units.
+	DurationTimeBase() int64
 	// TimeBase returns the numerator
 	// and the denominator of the stream
 	// time base fraction to convert
@@ -96,11 +126,19 @@ type Stream interface {
 	// FrameCount returns the total number
 	// of frames in the stream.
 	FrameCount() int64
+	// FrameCountIsExact returns true if the
+	// frame count is declared by the container
+	// and false if it's an estimate.
+	FrameCountIsExact() bool
 	// Open opens the stream for decoding.
 	Open() error
 	// Rewind rewinds the whole media to the
 	// specified time location based on the stream.
 	Rewind(time.Duration) error
+	// RewindWithFlags rewinds the whole media
+	// to the specified time location based on
+	// the stream with the specified seek flags.
+	RewindWithFlags(time.Duration, SeekFlags) error
 	// ApplyFilter applies a filter defined
 	// by the given string to the stream.
 	ApplyFilter(string) error
@@ -111,6 +149,18 @@ type Stream interface {
 	// RemoveFilter removes the currently applied
 	// filter from the stream and frees its memory.
 	RemoveFilter() error
+	// CodecParameters returns a copy of the
+	// codec parameters of the stream.
+	CodecParameters() *CodecParameters
+	// ParametersFingerprint returns the hash
+	// of the codec parameters of the stream.
+	ParametersFingerprint() []byte
+	// Stats returns the decoding
+	// counters of the stream.
+	Stats() StreamStats
+	// Metadata returns the tags
+	// of the stream.
+	Metadata() map[string]string
 	// ReadFrame decodes the next frame from the stream.
 	ReadFrame() (Frame, bool, error)
 	// Closes the stream for decoding.
@@ -132,6 +182,15 @@ type baseStream struct {
 	filterOutPacket *C.AVPacket
 	skip            bool
 	opened          bool
+	stats           StreamStats
+	codecFlags2     C.int
+	hwDeviceType    C.enum_AVHWDeviceType
+	hwDeviceCtx     *C.AVBufferRef
+	hwPixFmt        C.enum_AVPixelFormat
+	hwFrame         *C.AVFrame
+	unwrap          ptsUnwrapper
+	threadCount     int
+	decoderOptions  map[string]string
 }
 
 // Opened returns 'true' if the stream
@@ -190,6 +249,27 @@ func (stream *baseStream) Duration() (time.Duration, error) {
 	return time.ParseDuration(fmt.Sprintf("%fs", tm))
 }
 
+// DurationTimeBase returns the duration of
+// the stream in time base units. It can be
+// converted with the TimeBase factor without
+// losing precision.
+func (stream *baseStream) DurationTimeBase() int64 {
+	dur := int64(stream.inner.duration)
+
+	if dur < 0 {
+		dur = 0
+	}
+
+	return dur
+}
+
+// Metadata returns the tags of the stream
+// (e.g., language, title or rotate)
+// with the lowercase keys.
+func (stream *baseStream) Metadata() map[string]string {
+	return dictionaryEntries(stream.inner.metadata)
+}
+
 // TimeBase the numerator and the denominator of the
 // stream time base factor fraction.
 //
@@ -210,8 +290,34 @@ func (stream *baseStream) FrameRate() (int, int) {
 
 // FrameCount returns the total number of frames
 // in the stream.
+//
+// If the media container doesn't declare the number
+// of frames, it's estimated from the duration and
+// the frame rate of the stream.
 func (stream *baseStream) FrameCount() int64 {
-	return int64(stream.inner.nb_frames)
+	if stream.inner.nb_frames > 0 {
+		return int64(stream.inner.nb_frames)
+	}
+
+	dur := stream.inner.duration
+	tbNum, tbDen := stream.TimeBase()
+	frNum, frDen := stream.FrameRate()
+
+	if dur <= 0 || tbNum <= 0 || tbDen <= 0 ||
+		frNum <= 0 || frDen <= 0 {
+		return 0
+	}
+
+	seconds := float64(dur) * float64(tbNum) / float64(tbDen)
+
+	return int64(seconds*float64(frNum)/float64(frDen) + 0.5)
+}
+
+// FrameCountIsExact returns true if the frame
+// count of the stream is declared by the media
+// container and false if it's an estimate.
+func (stream *baseStream) FrameCountIsExact() bool {
+	return stream.inner.nb_frames > 0
 }
 
 // ApplyFilter applies a filter defined
@@ -221,22 +327,22 @@ func (stream *baseStream) ApplyFilter(args string) error {
 		C.CString(args), &stream.filterCtx)
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't create a filter context", status)
+		return newAVError(status,
+			"couldn't create a filter context")
 	}
 
 	status = C.avcodec_parameters_copy(stream.filterCtx.par_in, stream.codecParams)
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't copy the input codec parameters to the filter", status)
+		return newAVError(status,
+			"couldn't copy the input codec parameters to the filter")
 	}
 
 	status = C.avcodec_parameters_copy(stream.filterCtx.par_out, stream.codecParams)
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't copy the output codec parameters to the filter", status)
+		return newAVError(status,
+			"couldn't copy the output codec parameters to the filter")
 	}
 
 	stream.filterCtx.time_base_in = stream.inner.time_base
@@ -245,8 +351,8 @@ func (stream *baseStream) ApplyFilter(args string) error {
 	status = C.av_bsf_init(stream.filterCtx)
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't initialize the filter context", status)
+		return newAVError(status,
+			"couldn't initialize the filter context")
 	}
 
 	stream.filterInPacket = C.av_packet_alloc()
@@ -279,7 +385,7 @@ func (stream *baseStream) Filter() string {
 // filter from the stream and frees its memory.
 func (stream *baseStream) RemoveFilter() error {
 	if stream.filterCtx == nil {
-		return fmt.Errorf("no filter applied")
+		return ErrNoFilter
 	}
 
 	C.av_bsf_free(&stream.filterCtx)
@@ -303,18 +409,41 @@ func (stream *baseStream) RemoveFilter() error {
 // the streams of the playback to
 // desynchronyze.
 func (stream *baseStream) Rewind(t time.Duration) error {
+	return stream.RewindWithFlags(t, SeekFrame|SeekBackward)
+}
+
+// RewindWithFlags rewinds the stream to the specified
+// time position with the specified seek flags, e.g.,
+// SeekAny to seek to a non-keyframe or without
+// SeekBackward to seek forward. The decoding state
+// of all the streams of the media is reset.
+func (stream *baseStream) RewindWithFlags(t time.Duration, flags SeekFlags) error {
+	if flags&SeekByte != 0 {
+		return fmt.Errorf(
+			"use SeekByte of the media to seek by bytes")
+	}
+
 	tmNum, tmDen := stream.TimeBase()
 	factor := float64(tmDen) / float64(tmNum)
 	seconds := t.Seconds()
 	dur := int64(seconds * factor)
 
-	status := C.av_seek_frame(stream.media.ctx,
-		stream.inner.index, rewindPosition(dur),
-		C.AVSEEK_FLAG_FRAME|C.AVSEEK_FLAG_BACKWARD)
+	status := stream.media.seek(stream.inner.index,
+		dur, C.int(flags))
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't rewind the stream", status)
+		return newAVError(status,
+			"couldn't rewind the stream")
+	}
+
+	return stream.media.resetStreams()
+}
+
+// reset drops the reference frames buffered
+// by the decoder from the previous position.
+func (stream *baseStream) reset() error {
+	if stream.codecCtx != nil && stream.opened {
+		C.avcodec_flush_buffers(stream.codecCtx)
 	}
 
 	return nil
@@ -355,20 +484,44 @@ func (stream *baseStream) open() error {
 		stream.codecCtx, stream.codecParams)
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't send codec parameters to the context", status)
+		return newAVError(status,
+			"couldn't send codec parameters to the context")
 	}
 
-	status = C.avcodec_open2(stream.codecCtx, stream.codec, nil)
+	stream.codecCtx.flags2 |= stream.codecFlags2
 
-	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't open the codec context", status)
+	if stream.hwDeviceType != C.AV_HWDEVICE_TYPE_NONE {
+		err := stream.initHWDevice()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	threadCount, err := threads.acquire()
+
+	if err != nil {
+		return err
+	}
+
+	stream.threadCount = threadCount
+
+	if stream.threadCount > 0 {
+		stream.codecCtx.thread_count = C.int(stream.threadCount)
+	}
+
+	err = stream.openCodec()
+
+	if err != nil {
+		stream.releaseThreads()
+		return err
 	}
 
 	stream.frame = C.av_frame_alloc()
 
 	if stream.frame == nil {
+		stream.releaseThreads()
+
 		return fmt.Errorf(
 			"couldn't allocate a new frame")
 	}
@@ -378,9 +531,28 @@ func (stream *baseStream) open() error {
 	return nil
 }
 
+// releaseThreads returns the threads of
+// the decoder of the stream to the budget.
+func (stream *baseStream) releaseThreads() {
+	threads.release(stream.threadCount)
+	stream.threadCount = 0
+}
+
 // read decodes the packet and obtains a
 // frame from it.
 func (stream *baseStream) read() (bool, error) {
+	err := stream.media.checkDecodeLimits()
+
+	if err != nil {
+		stream.skip = false
+		return false, err
+	}
+
+	start := time.Now()
+	defer func() {
+		stream.media.decodeTime += time.Since(start)
+	}()
+
 	readPacket := stream.media.packet
 
 	if stream.filterCtx != nil {
@@ -393,8 +565,15 @@ func (stream *baseStream) read() (bool, error) {
 	if status < 0 {
 		stream.skip = false
 
-		return false, fmt.Errorf(
-			"%d: couldn't send the packet to the codec context", status)
+		// The decoder has been fully drained.
+		if status == C.int(ErrorEndOfFile) {
+			return false, stream.media.endOfFile()
+		}
+
+		stream.stats.DecodeErrors++
+
+		return false, newAVError(status,
+			"couldn't send the packet to the codec context")
 	}
 
 	status = C.avcodec_receive_frame(
@@ -403,13 +582,22 @@ func (stream *baseStream) read() (bool, error) {
 	if status < 0 {
 		if status == C.int(ErrorAgain) {
 			stream.skip = true
+			stream.stats.FramesSkipped++
 			return true, nil
 		}
 
 		stream.skip = false
 
-		return false, fmt.Errorf(
-			"%d: couldn't receive the frame from the codec context", status)
+		// No more frames to drain
+		// from the decoder.
+		if status == C.int(ErrorEndOfFile) {
+			return false, stream.media.endOfFile()
+		}
+
+		stream.stats.DecodeErrors++
+
+		return false, newAVError(status,
+			"couldn't receive the frame from the codec context")
 	}
 
 	C.av_packet_unref(stream.media.packet)
@@ -423,20 +611,26 @@ func (stream *baseStream) read() (bool, error) {
 	}
 
 	stream.skip = false
+	stream.stats.FramesDecoded++
+	stream.media.framesDecoded++
 
 	return true, nil
 }
 
 // close closes the stream for decoding.
 func (stream *baseStream) close() error {
+	// The threads are returned to the budget
+	// even if closing the codec fails.
+	defer stream.releaseThreads()
+
 	C.av_free(unsafe.Pointer(stream.frame))
 	stream.frame = nil
 
 	status := C.avcodec_close(stream.codecCtx)
 
 	if status < 0 {
-		return fmt.Errorf(
-			"%d: couldn't close the codec", status)
+		return newAVError(status,
+			"couldn't close the codec")
 	}
 
 	if stream.filterCtx != nil {
@@ -454,6 +648,7 @@ func (stream *baseStream) close() error {
 		stream.filterOutPacket = nil
 	}
 
+	stream.freeHWDevice()
 	stream.opened = false
 
 	return nil
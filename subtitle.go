@@ -0,0 +1,220 @@
+package reisen
+
+// #cgo pkg-config: libavformat libavcodec libavutil
+// #include <libavcodec/avcodec.h>
+// #include <libavformat/avformat.h>
+// #include <libavutil/avutil.h>
+import "C"
+import (
+	"image"
+	"time"
+	"unsafe"
+)
+
+// SubtitleStream is a stream
+// containing subtitles.
+type SubtitleStream struct {
+	baseStream
+}
+
+// SubtitleBitmap is a rectangle of a bitmap
+// subtitle (e.g., DVD VOBSUB or Blu-ray PGS)
+// to be overlaid onto the video.
+type SubtitleBitmap struct {
+	// Image contains the pixels
+	// of the subtitle rectangle.
+	Image *image.RGBA
+	// X and Y are the position of the top left
+	// corner of the rectangle on the video.
+	X, Y int
+	// Start and End are the time offsets at which
+	// the display of the subtitle begins and ends.
+	Start time.Duration
+	End   time.Duration
+}
+
+// SubtitleStreams returns all the
+// subtitle streams of the media file.
+func (media *Media) SubtitleStreams() []*SubtitleStream {
+	subtitleStreams := []*SubtitleStream{}
+
+	for _, stream := range media.streams {
+		if subtitleStream, ok := stream.(*SubtitleStream); ok {
+			subtitleStreams = append(subtitleStreams, subtitleStream)
+		}
+	}
+
+	return subtitleStreams
+}
+
+// Open opens the subtitle stream
+// to decode subtitles from it.
+func (subtitle *SubtitleStream) Open() error {
+	return subtitle.open()
+}
+
+// ReadFrame reads a new frame from the stream.
+func (subtitle *SubtitleStream) ReadFrame() (Frame, bool, error) {
+	return subtitle.ReadSubtitleFrame()
+}
+
+// ReadSubtitleFrame decodes the current packet of
+// the stream and returns the subtitle contained in
+// it. The frame is nil if the packet doesn't
+// complete a subtitle.
+func (subtitle *SubtitleStream) ReadSubtitleFrame() (*SubtitleFrame, bool, error) {
+	var sub C.AVSubtitle
+	got, pts, err := subtitle.decode(&sub)
+
+	if err != nil || !got {
+		return nil, err == nil, err
+	}
+
+	defer C.avsubtitle_free(&sub)
+
+	frame := new(SubtitleFrame)
+	frame.stream = subtitle
+	frame.pts = pts
+	frame.start, frame.end = subtitle.displayTimes(&sub, pts)
+	rects := unsafe.Slice(sub.rects, sub.num_rects)
+
+	for _, rect := range rects {
+		frame.rects = append(frame.rects, newSubtitleRect(rect))
+	}
+
+	return frame, true, nil
+}
+
+// ReadBitmap decodes the current packet of the
+// stream and returns the bitmap rectangles of the
+// subtitle. The slice is empty if the packet doesn't
+// complete a subtitle or the subtitle has no bitmaps.
+func (subtitle *SubtitleStream) ReadBitmap() ([]*SubtitleBitmap, bool, error) {
+	var sub C.AVSubtitle
+	got, pts, err := subtitle.decode(&sub)
+
+	if err != nil || !got {
+		return nil, err == nil, err
+	}
+
+	defer C.avsubtitle_free(&sub)
+
+	start, end := subtitle.displayTimes(&sub, pts)
+	rects := unsafe.Slice(sub.rects, sub.num_rects)
+	bitmaps := []*SubtitleBitmap{}
+
+	for _, rect := range rects {
+		if rect._type != C.SUBTITLE_BITMAP || rect.w <= 0 || rect.h <= 0 {
+			continue
+		}
+
+		bitmaps = append(bitmaps, &SubtitleBitmap{
+			Image: subtitleImage(rect),
+			X:     int(rect.x),
+			Y:     int(rect.y),
+			Start: start,
+			End:   end,
+		})
+	}
+
+	return bitmaps, true, nil
+}
+
+// decode decodes the current packet of the stream
+// into the subtitle and returns the timestamp of
+// the packet. It returns false if the packet
+// doesn't complete a subtitle.
+//
+// The subtitle must be freed with
+// C.avsubtitle_free afterwards.
+func (subtitle *SubtitleStream) decode(sub *C.AVSubtitle) (bool, int64, error) {
+	readPacket := subtitle.media.packet
+
+	if subtitle.filterCtx != nil {
+		readPacket = subtitle.filterOutPacket
+	}
+
+	var gotSub C.int
+
+	status := C.avcodec_decode_subtitle2(
+		subtitle.codecCtx, sub, &gotSub, readPacket)
+
+	if status < 0 {
+		subtitle.stats.DecodeErrors++
+
+		return false, noPTS, newAVError(status,
+			"couldn't decode the subtitle")
+	}
+
+	if gotSub == 0 {
+		return false, noPTS, nil
+	}
+
+	subtitle.stats.FramesDecoded++
+
+	return true, int64(readPacket.pts), nil
+}
+
+// displayTimes returns the time offsets at which the
+// display of the subtitle begins and ends.
+func (subtitle *SubtitleStream) displayTimes(sub *C.AVSubtitle, pts int64) (time.Duration, time.Duration) {
+	var base time.Duration
+
+	if int64(sub.pts) != noPTS {
+		// The pts of the subtitle is in AV_TIME_BASE
+		// units, i.e. microseconds.
+		base = time.Duration(sub.pts) * time.Microsecond
+	} else if pts != noPTS {
+		tbNum, tbDen := subtitle.TimeBase()
+		base = time.Duration(float64(pts) *
+			float64(tbNum) / float64(tbDen) * float64(time.Second))
+	}
+
+	start := base + time.Duration(sub.start_display_time)*time.Millisecond
+	end := base + time.Duration(sub.end_display_time)*time.Millisecond
+
+	return start, end
+}
+
+// subtitleImage converts the palettized pixels
+// of the subtitle rectangle to an RGBA image.
+func subtitleImage(rect *C.AVSubtitleRect) *image.RGBA {
+	width := int(rect.w)
+	height := int(rect.h)
+	stride := int(rect.linesize[0])
+	indices := unsafe.Slice((*byte)(
+		unsafe.Pointer(rect.data[0])), stride*height)
+	// The palette consists of native-endian
+	// 32-bit ARGB values with straight alpha.
+	palette := unsafe.Slice((*uint32)(
+		unsafe.Pointer(rect.data[1])), rect.nb_colors)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			index := int(indices[y*stride+x])
+
+			if index >= len(palette) {
+				continue
+			}
+
+			argb := palette[index]
+			a := argb >> 24
+			offset := y*img.Stride + x*4
+
+			// image.RGBA is alpha-premultiplied.
+			img.Pix[offset] = uint8((argb >> 16 & 0xff) * a / 0xff)
+			img.Pix[offset+1] = uint8((argb >> 8 & 0xff) * a / 0xff)
+			img.Pix[offset+2] = uint8((argb & 0xff) * a / 0xff)
+			img.Pix[offset+3] = uint8(a)
+		}
+	}
+
+	return img
+}
+
+// Close closes the subtitle stream
+// and stops decoding subtitles.
+func (subtitle *SubtitleStream) Close() error {
+	return subtitle.close()
+}
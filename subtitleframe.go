@@ -0,0 +1,137 @@
+package reisen
+
+// #cgo pkg-config: libavcodec
+// #include <libavcodec/avcodec.h>
+import "C"
+import (
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// SubtitleType is a type of
+// a subtitle rectangle.
+type SubtitleType int
+
+const (
+	// SubtitleTypeNone denotes the rectangle without data.
+	SubtitleTypeNone SubtitleType = C.SUBTITLE_NONE
+	// SubtitleTypeBitmap denotes the palettized bitmap.
+	SubtitleTypeBitmap SubtitleType = C.SUBTITLE_BITMAP
+	// SubtitleTypeText denotes the plain text.
+	SubtitleTypeText SubtitleType = C.SUBTITLE_TEXT
+	// SubtitleTypeASS denotes the text
+	// in the ASS dialogue format.
+	SubtitleTypeASS SubtitleType = C.SUBTITLE_ASS
+)
+
+// SubtitleRect is a rectangle
+// of a decoded subtitle.
+type SubtitleRect struct {
+	// Type is the type of the rectangle.
+	Type SubtitleType
+	// Text is the plain text of the
+	// text-based subtitles (e.g., SRT).
+	Text string
+	// ASS is the ASS dialogue line of the
+	// styled subtitles (e.g., ASS or SRT
+	// converted by the decoder).
+	ASS string
+	// X, Y, Width and Height are the position
+	// and the size of the bitmap on the video.
+	X, Y, Width, Height int
+	// Data contains the palette indices of the
+	// bitmap pixels, Stride bytes per row.
+	Data   []byte
+	Stride int
+	// Palette contains the 32-bit ARGB
+	// colors of the bitmap.
+	Palette []uint32
+}
+
+// SubtitleFrame is a subtitle
+// obtained from a subtitle stream.
+type SubtitleFrame struct {
+	baseFrame
+	rects []SubtitleRect
+	start time.Duration
+	end   time.Duration
+}
+
+// Data returns the text of the
+// subtitle rectangles line by line.
+func (frame *SubtitleFrame) Data() []byte {
+	return []byte(frame.Text())
+}
+
+// Text returns the text of the
+// subtitle rectangles line by line.
+func (frame *SubtitleFrame) Text() string {
+	lines := []string{}
+
+	for _, rect := range frame.rects {
+		switch {
+		case rect.Text != "":
+			lines = append(lines, rect.Text)
+
+		case rect.ASS != "":
+			lines = append(lines, rect.ASS)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Rects returns the rectangles of the subtitle.
+func (frame *SubtitleFrame) Rects() []SubtitleRect {
+	rects := make([]SubtitleRect, len(frame.rects))
+	copy(rects, frame.rects)
+
+	return rects
+}
+
+// Start returns the time offset at which
+// the display of the subtitle begins.
+func (frame *SubtitleFrame) Start() time.Duration {
+	return frame.start
+}
+
+// End returns the time offset at which
+// the display of the subtitle ends.
+func (frame *SubtitleFrame) End() time.Duration {
+	return frame.end
+}
+
+// newSubtitleRect copies the data
+// of the libAV subtitle rectangle.
+func newSubtitleRect(rect *C.AVSubtitleRect) SubtitleRect {
+	result := SubtitleRect{
+		Type:   SubtitleType(rect._type),
+		X:      int(rect.x),
+		Y:      int(rect.y),
+		Width:  int(rect.w),
+		Height: int(rect.h),
+	}
+
+	if rect.text != nil {
+		result.Text = C.GoString(rect.text)
+	}
+
+	if rect.ass != nil {
+		result.ASS = C.GoString(rect.ass)
+	}
+
+	if rect._type == C.SUBTITLE_BITMAP && rect.data[0] != nil && rect.h > 0 {
+		result.Stride = int(rect.linesize[0])
+		result.Data = C.GoBytes(unsafe.Pointer(rect.data[0]),
+			rect.linesize[0]*rect.h)
+
+		if rect.data[1] != nil && rect.nb_colors > 0 {
+			palette := unsafe.Slice((*uint32)(
+				unsafe.Pointer(rect.data[1])), rect.nb_colors)
+			result.Palette = append([]uint32(nil), palette...)
+		}
+	}
+
+	return result
+}
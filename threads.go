@@ -0,0 +1,125 @@
+package reisen
+
+import "sync"
+
+// threadBudget bounds the total number of
+// the decoding threads of all the streams.
+type threadBudget struct {
+	mu       sync.Mutex
+	max      int
+	expected int
+	used     int
+	decoders int
+}
+
+// threads is the global budget of the decoding threads.
+var threads threadBudget
+
+// SetMaxTotalThreads caps the total number of the
+// threads used by all the decoders opened afterwards,
+// so decoding many streams concurrently doesn't
+// oversubscribe the CPU. The value not greater than 0
+// removes the limit, and the decoders choose the
+// thread count automatically.
+//
+// Every decoder receives an equal share of the threads
+// for the number of the decoders set with
+// SetExpectedDecoders (or the number of the opened
+// ones if it's greater) but at least one and never
+// more than the threads left. Once all the threads
+// are in use, opening another decoder fails with
+// ErrNoThreads until one of them is closed.
+func SetMaxTotalThreads(n int) {
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+
+	threads.max = n
+}
+
+// SetExpectedDecoders sets the number of the decoders
+// expected to be opened concurrently, so the threads
+// of the budget set with SetMaxTotalThreads are split
+// between them and the first ones don't take all the
+// threads. The value not greater than 0 lets every
+// new decoder share the threads with the opened ones.
+func SetExpectedDecoders(n int) {
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+
+	threads.expected = n
+}
+
+// acquire returns the number of threads for a new
+// decoder or 0 if the number is not limited. It
+// returns ErrNoThreads if all the threads are used.
+func (budget *threadBudget) acquire() (int, error) {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	if budget.max <= 0 {
+		return 0, nil
+	}
+
+	left := budget.max - budget.used
+
+	if left < 1 {
+		return 0, ErrNoThreads
+	}
+
+	decoders := budget.decoders + 1
+
+	if budget.expected > decoders {
+		decoders = budget.expected
+	}
+
+	share := budget.max / decoders
+
+	if share > left {
+		share = left
+	}
+
+	if share < 1 {
+		share = 1
+	}
+
+	budget.used += share
+	budget.decoders++
+
+	return share, nil
+}
+
+// acquireOne returns a single thread for a new
+// single-threaded decoder or 0 if the number is
+// not limited. It returns ErrNoThreads if all
+// the threads are used.
+func (budget *threadBudget) acquireOne() (int, error) {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	if budget.max <= 0 {
+		return 0, nil
+	}
+
+	if budget.used >= budget.max {
+		return 0, ErrNoThreads
+	}
+
+	budget.used++
+	budget.decoders++
+
+	return 1, nil
+}
+
+// release returns the threads
+// of a closed decoder.
+func (budget *threadBudget) release(n int) {
+	if n <= 0 {
+		return
+	}
+
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	budget.used -= n
+	budget.decoders--
+}
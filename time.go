@@ -3,9 +3,42 @@ package reisen
 // #cgo pkg-config: libavutil
 // #include <libavutil/avutil.h>
 import "C"
+import (
+	"math"
+	"time"
+)
 
 const (
 	// TimeBase is a global time base
 	// used for describing media containers.
 	TimeBase int = C.AV_TIME_BASE
 )
+
+// noPTS is the value of AV_NOPTS_VALUE
+// denoting an undefined timestamp.
+const noPTS int64 = math.MinInt64
+
+// wallClock returns the absolute wall-clock time
+// of the timestamp in the time base of the stream
+// if the media container provides the real-world
+// time of its start (e.g., from the RTCP sender
+// reports of an RTSP stream).
+func (media *Media) wallClock(stream Stream, pts int64) (time.Time, bool) {
+	start := int64(media.ctx.start_time_realtime)
+
+	if start == noPTS || start == 0 || pts == noPTS {
+		return time.Time{}, false
+	}
+
+	tbNum, tbDen := stream.TimeBase()
+
+	if tbNum <= 0 || tbDen <= 0 {
+		return time.Time{}, false
+	}
+
+	offset := float64(pts) * float64(tbNum) /
+		float64(tbDen) * float64(time.Second)
+
+	return time.UnixMicro(start).Add(
+		time.Duration(offset)).UTC(), true
+}
@@ -0,0 +1,72 @@
+package reisen
+
+import (
+	"fmt"
+	"time"
+)
+
+// StreamTiming holds the timing
+// parameters of a media stream.
+type StreamTiming struct {
+	// Index is the index of the stream.
+	Index int
+	// Type is the type of the stream.
+	Type StreamType
+	// TimeBaseNum and TimeBaseDen are
+	// the time base fraction of the stream.
+	TimeBaseNum int
+	TimeBaseDen int
+	// RealFrameRateNum and RealFrameRateDen are
+	// the lowest frame rate with which all the
+	// timestamps of the stream can be represented.
+	RealFrameRateNum int
+	RealFrameRateDen int
+	// AvgFrameRateNum and AvgFrameRateDen
+	// are the average frame rate.
+	AvgFrameRateNum int
+	AvgFrameRateDen int
+	// StartTime is the presentation
+	// time of the first frame.
+	StartTime time.Duration
+	// StartTimeKnown is false if the
+	// start time is undefined.
+	StartTimeKnown bool
+}
+
+// StreamTimings returns the timing
+// parameters of all the streams.
+func (media *Media) StreamTimings() []StreamTiming {
+	timings := make([]StreamTiming, 0, len(media.streams))
+
+	for _, stream := range media.streams {
+		inner := stream.innerStream()
+		timing := StreamTiming{
+			Index:            int(inner.index),
+			Type:             stream.Type(),
+			TimeBaseNum:      int(inner.time_base.num),
+			TimeBaseDen:      int(inner.time_base.den),
+			RealFrameRateNum: int(inner.r_frame_rate.num),
+			RealFrameRateDen: int(inner.r_frame_rate.den),
+			AvgFrameRateNum:  int(inner.avg_frame_rate.num),
+			AvgFrameRateDen:  int(inner.avg_frame_rate.den),
+		}
+
+		start := int64(inner.start_time)
+
+		if start != noPTS && timing.TimeBaseDen > 0 {
+			tm := float64(start) * float64(timing.TimeBaseNum) /
+				float64(timing.TimeBaseDen)
+			startTime, err := time.ParseDuration(
+				fmt.Sprintf("%fs", tm))
+
+			if err == nil {
+				timing.StartTime = startTime
+				timing.StartTimeKnown = true
+			}
+		}
+
+		timings = append(timings, timing)
+	}
+
+	return timings
+}
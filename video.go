@@ -6,6 +6,7 @@ package reisen
 // #include <libavutil/avutil.h>
 // #include <libavutil/imgutils.h>
 // #include <libswscale/swscale.h>
+// #include <libavutil/pixdesc.h>
 // #include <inttypes.h>
 import "C"
 import (
@@ -17,9 +18,35 @@ import (
 // video frames.
 type VideoStream struct {
 	baseStream
-	swsCtx    *C.struct_SwsContext
-	rgbaFrame *C.AVFrame
-	bufSize   C.int
+	swsCtx        *C.struct_SwsContext
+	rgbaFrame     *C.AVFrame
+	bufSize       C.int
+	dstWidth      int
+	dstHeight     int
+	interpolation InterpolationAlgorithm
+	outFormat     C.enum_AVPixelFormat
+	srcWidth      C.int
+	srcHeight     C.int
+	srcFormat     C.enum_AVPixelFormat
+	deinterlace   bool
+	deintGraph    *filterGraph
+	deintArgs     string
+	coverArtRead  bool
+	reorderDepth  int
+	reorderBuffer []*VideoFrame
+	decodeAhead   *decodeAhead
+	yuvOnly       bool
+	hwScale       bool
+	hwScaleGraph  *filterGraph
+	hwScaleArgs   string
+	frameCache    *frameCache
+	reusedFrame   *VideoFrame
+	outAlign      int
+	autoRotate    bool
+	rotateGraph   *filterGraph
+	rotateArgs    string
+	videoFilter   *VideoFilterGraph
+	drainFilter   bool
 }
 
 // AspectRatio returns the fraction of the video
@@ -41,18 +68,130 @@ func (video *VideoStream) Height() int {
 	return int(video.codecParams.height)
 }
 
+// HasBFrames returns true if the stream uses
+// bidirectionally predicted frames, which add the
+// decoding latency of reordering the frames.
+//
+// The value reported by the decoder is used when
+// the stream is opened, otherwise it's the video
+// delay declared by the codec parameters.
+func (video *VideoStream) HasBFrames() bool {
+	if video.opened && video.codecCtx != nil {
+		return video.codecCtx.has_b_frames > 0
+	}
+
+	return video.codecParams.video_delay > 0
+}
+
 // OpenDecode opens the video stream for
 // decoding with default parameters.
 func (video *VideoStream) Open() error {
-	return video.OpenDecode(
+	width, height := video.rotatedSize(
 		int(video.codecParams.width),
-		int(video.codecParams.height),
+		int(video.codecParams.height))
+
+	return video.OpenDecode(width, height,
 		InterpolationBicubic)
 }
 
+// OpenDisplayAccurate opens the video stream for
+// decoding frames at the display resolution,
+// i.e., the frames of anamorphic video (with
+// non-square pixels) are stretched horizontally
+// according to the sample aspect ratio, so the
+// output pixels are square.
+func (video *VideoStream) OpenDisplayAccurate(alg InterpolationAlgorithm) error {
+	width, height := video.rotatedSize(video.DisplaySize())
+	return video.OpenDecode(width, height, alg)
+}
+
+// DisplaySize returns the resolution of the video
+// frames with square pixels taking the sample
+// aspect ratio into account.
+func (video *VideoStream) DisplaySize() (int, int) {
+	width := int(video.codecParams.width)
+	height := int(video.codecParams.height)
+	sar := C.av_guess_sample_aspect_ratio(
+		video.media.ctx, video.inner, nil)
+
+	if sar.num <= 0 || sar.den <= 0 || sar.num == sar.den {
+		return width, height
+	}
+
+	width = int((int64(width)*int64(sar.num) +
+		int64(sar.den)/2) / int64(sar.den))
+
+	return width, height
+}
+
 // OpenDecode opens the video stream for
 // decoding with the specified parameters.
 func (video *VideoStream) OpenDecode(width, height int, alg InterpolationAlgorithm) error {
+	return video.openDecode(width, height, alg, C.AV_PIX_FMT_RGBA)
+}
+
+// OpenDecodeGray16 opens the video stream for
+// decoding frames into 16-bit grayscale images
+// retaining the precision of high bit-depth
+// sources. The frames are obtained with
+// VideoFrame.Gray16.
+func (video *VideoStream) OpenDecodeGray16(width, height int, alg InterpolationAlgorithm) error {
+	return video.openDecode(width, height, alg, C.AV_PIX_FMT_GRAY16BE)
+}
+
+// OpenDecodeGray opens the video stream for
+// decoding frames into 8-bit grayscale images
+// using a quarter of the memory of RGBA. The
+// frames are obtained with VideoFrame.Gray.
+func (video *VideoStream) OpenDecodeGray(width, height int, alg InterpolationAlgorithm) error {
+	return video.openDecode(width, height, alg, C.AV_PIX_FMT_GRAY8)
+}
+
+// OpenDecodeWithFormat opens the video stream for
+// decoding frames in the specified pixel format.
+// The pixels of the frames are obtained with
+// VideoFrame.Data and VideoFrame.Stride.
+func (video *VideoStream) OpenDecodeWithFormat(width, height int, alg InterpolationAlgorithm, pixFmt PixelFormat) error {
+	return video.openDecode(width, height, alg, C.enum_AVPixelFormat(pixFmt))
+}
+
+// OutputPixelFormat returns the pixel format
+// of the decoded frames.
+func (video *VideoStream) OutputPixelFormat() PixelFormat {
+	return PixelFormat(video.outFormat)
+}
+
+// SetOutputAlignment sets the alignment (in bytes,
+// a power of 2) to which the rows of the decoded
+// frames are padded, e.g., 256 for GPU texture
+// uploads. It must be called before opening the
+// stream for decoding. The default alignment of 1
+// means the rows are tightly packed.
+func (video *VideoStream) SetOutputAlignment(align int) error {
+	if align <= 0 || align&(align-1) != 0 {
+		return fmt.Errorf(
+			"the alignment %d is not a power of 2", align)
+	}
+
+	video.outAlign = align
+
+	return nil
+}
+
+// OutputStride returns the number of bytes between
+// the starts of the adjacent rows of the decoded
+// frames or 0 if the stream isn't opened.
+func (video *VideoStream) OutputStride() int {
+	if video.rgbaFrame == nil {
+		return 0
+	}
+
+	return int(video.rgbaFrame.linesize[0])
+}
+
+// openDecode opens the video stream for decoding
+// frames of the specified output pixel format.
+func (video *VideoStream) openDecode(width, height int, alg InterpolationAlgorithm, format C.enum_AVPixelFormat) error {
 	err := video.open()
 
 	if err != nil {
@@ -66,12 +205,18 @@ func (video *VideoStream) OpenDecode(width, height int, alg InterpolationAlgorit
 			"couldn't allocate a new RGBA frame")
 	}
 
+	align := video.outAlign
+
+	if align <= 0 {
+		align = 1
+	}
+
 	video.bufSize = C.av_image_get_buffer_size(
-		C.AV_PIX_FMT_RGBA, C.int(width), C.int(height), 1)
+		format, C.int(width), C.int(height), C.int(align))
 
 	if video.bufSize < 0 {
-		return fmt.Errorf(
-			"%d: couldn't get the buffer size", video.bufSize)
+		return newAVError(video.bufSize,
+			"couldn't get the buffer size")
 	}
 
 	buf := (*C.uint8_t)(unsafe.Pointer(
@@ -82,28 +227,86 @@ func (video *VideoStream) OpenDecode(width, height int, alg InterpolationAlgorit
 			"couldn't allocate an AV buffer")
 	}
 
+	// By default the buffer is filled with the
+	// alignment of 1, so the rows of pixels are
+	// tightly packed, otherwise each row is padded
+	// to a multiple of the output alignment.
 	status := C.av_image_fill_arrays(&video.rgbaFrame.data[0],
-		&video.rgbaFrame.linesize[0], buf, C.AV_PIX_FMT_RGBA,
-		C.int(width), C.int(height), 1)
+		&video.rgbaFrame.linesize[0], buf, format,
+		C.int(width), C.int(height), C.int(align))
 
 	if status < 0 {
+		return newAVError(status,
+			"couldn't fill the image arrays")
+	}
+
+	video.dstWidth = width
+	video.dstHeight = height
+	video.interpolation = alg
+	video.outFormat = format
+	video.yuvOnly = false
+
+	return video.initScaler(video.codecCtx.width,
+		video.codecCtx.height, video.codecCtx.pix_fmt)
+}
+
+// initScaler (re)creates the SWS context converting
+// decoded frames of the specified source resolution
+// and pixel format to the output frames.
+func (video *VideoStream) initScaler(width, height C.int, format C.enum_AVPixelFormat) error {
+	if video.swsCtx != nil {
+		C.sws_freeContext(video.swsCtx)
+		video.swsCtx = nil
+	}
+
+	if C.sws_isSupportedInput(format) == 0 {
+		return fmt.Errorf(
+			"the source pixel format %s is not supported by the scaler",
+			pixelFormatName(format))
+	}
+
+	if C.sws_isSupportedOutput(video.outFormat) == 0 {
 		return fmt.Errorf(
-			"%d: couldn't fill the image arrays", status)
+			"the output pixel format %s is not supported by the scaler",
+			pixelFormatName(video.outFormat))
 	}
 
-	video.swsCtx = C.sws_getContext(video.codecCtx.width,
-		video.codecCtx.height, video.codecCtx.pix_fmt,
-		C.int(width), C.int(height),
-		C.AV_PIX_FMT_RGBA, C.int(alg), nil, nil, nil)
+	video.swsCtx = C.sws_getContext(width, height, format,
+		C.int(video.dstWidth), C.int(video.dstHeight),
+		video.outFormat, C.int(video.interpolation), nil, nil, nil)
 
 	if video.swsCtx == nil {
 		return fmt.Errorf(
 			"couldn't create an SWS context")
 	}
 
+	video.srcWidth = width
+	video.srcHeight = height
+	video.srcFormat = format
+
 	return nil
 }
 
+// PixelFormatSupported returns true if the
+// scaler can convert the frames of the source
+// pixel format of the stream.
+func (video *VideoStream) PixelFormatSupported() bool {
+	return C.sws_isSupportedInput(
+		C.enum_AVPixelFormat(video.codecParams.format)) != 0
+}
+
+// pixelFormatName returns the
+// name of the pixel format.
+func pixelFormatName(format C.enum_AVPixelFormat) string {
+	name := C.av_get_pix_fmt_name(format)
+
+	if name == nil {
+		return fmt.Sprintf("%d", format)
+	}
+
+	return C.GoString(name)
+}
+
 // ReadFrame reads the next frame from the stream.
 func (video *VideoStream) ReadFrame() (Frame, bool, error) {
 	return video.ReadVideoFrame()
@@ -112,6 +315,223 @@ func (video *VideoStream) ReadFrame() (Frame, bool, error) {
 // ReadVideoFrame reads the next video frame
 // from the video stream.
 func (video *VideoStream) ReadVideoFrame() (*VideoFrame, bool, error) {
+	frame, ok, err := video.readVideoFrame()
+
+	if err != nil || !ok || frame == nil || video.reorderDepth <= 0 {
+		return frame, ok, err
+	}
+
+	return video.reorder(frame), true, nil
+}
+
+// readVideoFrame decodes the next video
+// frame in the order of the decoder output.
+func (video *VideoStream) readVideoFrame() (*VideoFrame, bool, error) {
+	return video.convertFrame(false)
+}
+
+// convertFrame decodes the next video frame and
+// converts it to the output format. If reuse is
+// set, the frame is backed by the output buffer
+// of the scaler instead of a copy of it.
+func (video *VideoStream) convertFrame(reuse bool) (*VideoFrame, bool, error) {
+	src, ok, err := video.nextSourceFrame()
+
+	if err != nil || !ok || src == nil {
+		return nil, ok, err
+	}
+
+	srcFormat := C.enum_AVPixelFormat(src.format)
+
+	C.sws_scale(video.swsCtx, &src.data[0],
+		&src.linesize[0], 0,
+		src.height,
+		&video.rgbaFrame.data[0],
+		&video.rgbaFrame.linesize[0])
+
+	var data []byte
+
+	if reuse {
+		data = unsafe.Slice((*byte)(unsafe.Pointer(
+			video.rgbaFrame.data[0])), int(video.bufSize))
+	} else {
+		data = C.GoBytes(unsafe.
+			Pointer(video.rgbaFrame.data[0]),
+			video.bufSize)
+	}
+
+	if video.media.options.ForceOpaque &&
+		video.outFormat == C.AV_PIX_FMT_RGBA && hasAlpha(srcFormat) {
+		fillOpaque(data)
+	}
+
+	pts := video.unwrapPTS(int64(src.pts))
+	stride := int(video.rgbaFrame.linesize[0])
+	var frame *VideoFrame
+
+	if reuse && video.reusedFrame.reusable(data, stride) {
+		frame = video.reusedFrame
+		frame.pts = pts
+		frame.indexCoded = int(src.coded_picture_number)
+		frame.indexDisplay = int(src.display_picture_number)
+		frame.motionVectors = nil
+	} else {
+		frame = newVideoFrame(video, pts,
+			int(src.coded_picture_number),
+			int(src.display_picture_number),
+			video.dstWidth, video.dstHeight, video.outFormat,
+			stride, data)
+	}
+	frame.repeatPict = int(src.repeat_pict)
+	frame.sarNum = int(src.sample_aspect_ratio.num)
+	frame.sarDen = int(src.sample_aspect_ratio.den)
+	frame.wallClock, frame.hasWallClock = video.media.
+		wallClock(video, pts)
+	video.coverArtRead = video.IsCoverArt()
+
+	if video.codecFlags2&C.AV_CODEC_FLAG2_EXPORT_MVS != 0 {
+		frame.motionVectors = frameMotionVectors(src)
+	}
+
+	frame.closedCaptions = frameClosedCaptions(src)
+	frame.hdr10Plus = frameHDR10Plus(src)
+	video.setFrameTimestamps(frame, src)
+
+	if reuse {
+		video.reusedFrame = frame
+	} else if video.frameCache != nil {
+		video.frameCache.put(frame)
+	}
+
+	return frame, true, nil
+}
+
+// setFrameTimestamps stores the original timestamps
+// of the decoded frame and its packet position.
+func (video *VideoStream) setFrameTimestamps(frame *VideoFrame, src *C.AVFrame) {
+	frame.rawPTS = int64(src.pts)
+	frame.packetPos = int64(src.pkt_pos)
+	codecTB := video.codecCtx.time_base
+
+	if codecTB.num <= 0 || codecTB.den <= 0 {
+		codecTB = video.inner.time_base
+	}
+
+	frame.codecTBNum = int(codecTB.num)
+	frame.codecTBDen = int(codecTB.den)
+	frame.codecPTS = frame.rawPTS
+
+	if frame.rawPTS != noPTS {
+		frame.codecPTS = int64(C.av_rescale_q(src.pts,
+			video.inner.time_base, codecTB))
+	}
+}
+
+// nextSourceFrame decodes the next frame to scale
+// and prepares the scaler for it. It returns a nil
+// frame if no frame is available for the current
+// packet and false if there's no more data.
+func (video *VideoStream) nextSourceFrame() (*C.AVFrame, bool, error) {
+	if video.yuvOnly {
+		return nil, false, fmt.Errorf(
+			"the stream is opened for reading YUV frames")
+	}
+
+	// The attached picture stream has exactly
+	// one frame, so there's no more data after
+	// it's been read.
+	if video.coverArtRead {
+		return nil, false, nil
+	}
+
+	src, ok, err := video.decodeFrame()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	// No more data.
+	if !ok {
+		return nil, false, nil
+	}
+
+	if src == nil {
+		return nil, true, nil
+	}
+
+	// The resolution or the pixel format of
+	// the source may change in the middle
+	// of the stream, so the SWS context
+	// has to be recreated accordingly.
+	srcFormat := C.enum_AVPixelFormat(src.format)
+
+	if src.width != video.srcWidth ||
+		src.height != video.srcHeight ||
+		srcFormat != video.srcFormat {
+		err = video.initScaler(src.width,
+			src.height, srcFormat)
+
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return src, true, nil
+}
+
+// ReadNextVideoFrame reads packets of the media until
+// the next video frame of the stream is decoded. Unlike
+// ReadVideoFrame, it never returns a nil frame with
+// true: it returns either an actual frame or false
+// upon reaching the end of the stream.
+//
+// The packets of the other streams read on the
+// way are dropped, so it's meant for decoding
+// the only stream of the media.
+func (video *VideoStream) ReadNextVideoFrame() (*VideoFrame, bool, error) {
+	for {
+		pkt, gotPacket, err := video.media.ReadPacket()
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !gotPacket {
+			return nil, false, nil
+		}
+
+		if pkt == nil || pkt.StreamIndex() != video.Index() {
+			continue
+		}
+
+		frame, gotFrame, err := video.ReadVideoFrame()
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !gotFrame {
+			return nil, false, nil
+		}
+
+		if frame != nil {
+			return frame, true, nil
+		}
+	}
+}
+
+// decodeFrame decodes the next frame of the
+// stream and passes it through the enabled
+// filters. It returns a nil frame if no frame
+// is available for the current packet.
+func (video *VideoStream) decodeFrame() (*C.AVFrame, bool, error) {
+	// Take the pending filtered
+	// frame instead of decoding.
+	if video.drainFilter {
+		src := video.videoFilter.next()
+		return src, src != nil, nil
+	}
+
 	ok, err := video.read()
 
 	if err != nil {
@@ -127,25 +547,92 @@ func (video *VideoStream) ReadVideoFrame() (*VideoFrame, bool, error) {
 		return nil, false, nil
 	}
 
-	C.sws_scale(video.swsCtx, &video.frame.data[0],
-		&video.frame.linesize[0], 0,
-		video.codecCtx.height,
-		&video.rgbaFrame.data[0],
-		&video.rgbaFrame.linesize[0])
+	scaled, got, err := video.hwScaleFrame(video.frame)
 
-	data := C.GoBytes(unsafe.
-		Pointer(video.rgbaFrame.data[0]),
-		video.bufSize)
-	frame := newVideoFrame(video, int64(video.frame.pts),
-		int(video.frame.coded_picture_number),
-		int(video.frame.display_picture_number),
-		int(video.codecCtx.width), int(video.codecCtx.height), data)
+	if err != nil {
+		return nil, false, err
+	}
 
-	return frame, true, nil
+	if !got {
+		return nil, true, nil
+	}
+
+	src, err := video.transferHWFrame(scaled)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if video.deinterlace {
+		deinterlaced, got, err := video.deinterlaceFrame(src)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !got {
+			return nil, true, nil
+		}
+
+		src = deinterlaced
+	}
+
+	if video.autoRotate {
+		rotated, got, err := video.rotateFrame(src)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !got {
+			return nil, true, nil
+		}
+
+		src = rotated
+	}
+
+	if video.videoFilter != nil {
+		filtered, err := video.filterFrame(src)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if filtered == nil {
+			return nil, true, nil
+		}
+
+		src = filtered
+	}
+
+	return src, true, nil
+}
+
+// reset drops the frames buffered by the decoder,
+// the filters and the reorder buffer of the
+// stream from the previous position.
+func (video *VideoStream) reset() error {
+	err := video.baseStream.reset()
+
+	if err != nil {
+		return err
+	}
+
+	video.coverArtRead = false
+	video.freeDeinterlacer()
+	video.freeRotator()
+	video.reorderBuffer = nil
+
+	if video.videoFilter != nil {
+		video.videoFilter.reset()
+	}
+
+	return nil
 }
 
 // Close closes the video stream for decoding.
 func (video *VideoStream) Close() error {
+	video.StopDecodeAhead()
 	err := video.close()
 
 	if err != nil {
@@ -156,6 +643,22 @@ func (video *VideoStream) Close() error {
 	video.rgbaFrame = nil
 	C.sws_freeContext(video.swsCtx)
 	video.swsCtx = nil
+	video.freeDeinterlacer()
+	video.freeHWScaler()
+	video.freeRotator()
+
+	if video.videoFilter != nil {
+		video.videoFilter.reset()
+	}
+
+	video.coverArtRead = false
+	video.reorderBuffer = nil
+	video.reusedFrame = nil
+	video.yuvOnly = false
+
+	if video.frameCache != nil {
+		video.frameCache.clear()
+	}
 
 	return nil
 }
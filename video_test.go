@@ -0,0 +1,241 @@
+package reisen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testFrameRate = 25
+	testWidth     = 32
+	testHeight    = 16
+)
+
+// writeTestY4M writes a YUV4MPEG2 file of the
+// specified number of frames (up to 25) to the
+// directory. The left half of every frame is
+// white, so the orientation of the decoded frames
+// is known, and the luma of the right half is
+// 16 + 8 * the index of the frame.
+func writeTestY4M(t *testing.T, dir string, frames int) string {
+	t.Helper()
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C420jpeg\n",
+		testWidth, testHeight, testFrameRate)
+
+	luma := make([]byte, testWidth*testHeight)
+	chroma := bytes.Repeat([]byte{128}, testWidth*testHeight/2)
+
+	for i := 0; i < frames; i++ {
+		for y := 0; y < testHeight; y++ {
+			for x := 0; x < testWidth; x++ {
+				luma[y*testWidth+x] = byte(16 + 8*i)
+
+				if x < testWidth/2 {
+					luma[y*testWidth+x] = 235
+				}
+			}
+		}
+
+		content.WriteString("FRAME\n")
+		content.Write(luma)
+		content.Write(chroma)
+	}
+
+	filename := filepath.Join(dir, "test.y4m")
+
+	if err := os.WriteFile(filename, content.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return filename
+}
+
+// openTestVideo opens the only video stream of the media
+// file, configures it and opens it with the default size.
+func openTestVideo(t *testing.T, filename string, configure func(*VideoStream)) (*Media, *VideoStream) {
+	t.Helper()
+
+	media, err := NewMedia(filename)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(media.Close)
+
+	if err := media.OpenDecode(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { media.CloseDecode() })
+	videoStreams := media.VideoStreams()
+
+	if len(videoStreams) != 1 {
+		t.Fatalf("got %d video streams, want 1", len(videoStreams))
+	}
+
+	video := videoStreams[0]
+
+	if configure != nil {
+		configure(video)
+	}
+
+	if err := video.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { video.Close() })
+
+	return media, video
+}
+
+// readTestVideoFrame reads the next video
+// frame. It returns nil at the end of the media.
+func readTestVideoFrame(t *testing.T, media *Media, video *VideoStream) *VideoFrame {
+	t.Helper()
+
+	for {
+		pkt, gotPacket, err := media.ReadPacket()
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Fatal(err)
+		}
+
+		if !gotPacket {
+			return nil
+		}
+
+		if pkt == nil || pkt.StreamIndex() != video.Index() {
+			continue
+		}
+
+		frame, gotFrame, err := video.ReadVideoFrame()
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Fatal(err)
+		}
+
+		if !gotFrame {
+			return nil
+		}
+
+		if frame != nil {
+			return frame
+		}
+	}
+}
+
+func TestOpenAutoRotatedSize(t *testing.T) {
+	dir := t.TempDir()
+	writeTestY4M(t, dir, 1)
+
+	// The concat demuxer sets the rotate tag
+	// of the stream the Y4M format can't hold.
+	playlist := filepath.Join(dir, "rotated.ffconcat")
+	content := "ffconcat version 1.0\n" +
+		"stream\n" +
+		"stream_meta rotate 90\n" +
+		"file test.y4m\n"
+
+	if err := os.WriteFile(playlist, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	media, video := openTestVideo(t, playlist, func(video *VideoStream) {
+		video.SetAutoRotate(true)
+	})
+
+	if rotation, _ := video.Rotation(); rotation != 90 {
+		t.Fatalf("got the rotation %d, want 90", rotation)
+	}
+
+	frame := readTestVideoFrame(t, media, video)
+
+	if frame == nil {
+		t.Fatal("no frame decoded")
+	}
+
+	img := frame.Image()
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	if width != testHeight || height != testWidth {
+		t.Fatalf("got a %dx%d frame, want %dx%d",
+			width, height, testHeight, testWidth)
+	}
+
+	// The white left half comes
+	// on top after the rotation.
+	top := img.RGBAAt(width/2, height/4)
+	bottom := img.RGBAAt(width/2, 3*height/4)
+
+	if top.R < 200 || bottom.R > 50 {
+		t.Errorf("got the top %v and the bottom %v, want white over black",
+			top, bottom)
+	}
+}
+
+// frameIndex returns the index of the test
+// frame from the luma of its right half.
+func frameIndex(frame *VideoFrame) int {
+	img := frame.Image()
+	value := int(img.RGBAAt(3*testWidth/4, testHeight/2).R)
+
+	return (value - 16 + 4) / 8
+}
+
+func TestRewindVideoToStart(t *testing.T) {
+	media, video := openTestVideo(t,
+		writeTestY4M(t, t.TempDir(), testFrameRate), nil)
+
+	if frame := readTestVideoFrame(t, media, video); frame == nil {
+		t.Fatal("no frame decoded")
+	}
+
+	// Seek forward first, so the frames
+	// of the stream are read past the start.
+	if err := video.RewindWithFlags(800*time.Millisecond, SeekFrame); err != nil {
+		t.Fatal(err)
+	}
+
+	frame := readTestVideoFrame(t, media, video)
+
+	if frame == nil {
+		t.Fatal("no frame after seeking forward")
+	}
+
+	if index := frameIndex(frame); index < testFrameRate/2 {
+		t.Fatalf("got the frame %d after seeking forward to 800ms", index)
+	}
+
+	if err := video.Rewind(0); err != nil {
+		t.Fatal(err)
+	}
+
+	frame = readTestVideoFrame(t, media, video)
+
+	if frame == nil {
+		t.Fatal("no frame after rewinding")
+	}
+
+	offset, err := frame.PresentationOffset()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if offset != 0 {
+		t.Errorf("got the frame at %v after rewinding, want 0s", offset)
+	}
+
+	if index := frameIndex(frame); index != 0 {
+		t.Errorf("got the frame %d after rewinding, want 0", index)
+	}
+}
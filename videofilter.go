@@ -0,0 +1,247 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/frame.h>
+import "C"
+import "fmt"
+
+// VideoFilterGraph is a libavfilter graph processing
+// the decoded frames of a video stream (e.g., with
+// the scale, crop, fps or hflip filters) before
+// they're converted to the output format.
+//
+// A single decoded frame may produce no filtered
+// frames or several of them (e.g., with the fps
+// filter), the extra ones are kept pending until
+// they're read.
+type VideoFilterGraph struct {
+	description string
+	graph       *filterGraph
+	srcArgs     string
+	pending     []*C.AVFrame
+	current     *C.AVFrame
+}
+
+// Description returns the description
+// of the filters of the graph.
+func (filter *VideoFilterGraph) Description() string {
+	return filter.description
+}
+
+// Pending returns the number of the filtered
+// frames waiting to be read.
+func (filter *VideoFilterGraph) Pending() int {
+	return len(filter.pending)
+}
+
+// ApplyVideoFilter applies the filter graph defined by
+// the description (e.g., "crop=640:360,hflip") to the
+// decoded frames of the stream. The filter graph comes
+// after the deinterlacer and the rotator if they're
+// enabled, and the filtered frames are scaled to the
+// output size the stream was opened with.
+//
+// The frames produced by the filters in addition to
+// the first one for a packet are obtained with
+// ReadPendingVideoFrame.
+func (video *VideoStream) ApplyVideoFilter(description string) error {
+	if description == "" {
+		return fmt.Errorf("the filter description is empty")
+	}
+
+	filter := &VideoFilterGraph{
+		description: description,
+	}
+
+	// Check the description with the parameters
+	// of the stream if they're known, so the
+	// mistakes are reported right away.
+	if video.codecParams.width > 0 && video.codecParams.height > 0 &&
+		video.codecParams.format >= 0 {
+		srcArgs := videoBufferArgs(video.codecParams.width,
+			video.codecParams.height, video.codecParams.format,
+			video.inner.time_base, video.codecParams.sample_aspect_ratio)
+		err := filter.configure(srcArgs)
+
+		if err != nil {
+			filter.reset()
+			return err
+		}
+	}
+
+	video.RemoveVideoFilter()
+	video.videoFilter = filter
+
+	return nil
+}
+
+// VideoFilter returns the filter graph applied
+// to the decoded frames of the stream or nil
+// if there's none.
+func (video *VideoStream) VideoFilter() *VideoFilterGraph {
+	return video.videoFilter
+}
+
+// RemoveVideoFilter removes the filter graph
+// applied to the decoded frames of the stream
+// and drops the pending filtered frames.
+func (video *VideoStream) RemoveVideoFilter() {
+	if video.videoFilter != nil {
+		video.videoFilter.reset()
+		video.videoFilter = nil
+	}
+}
+
+// ReadPendingVideoFrame reads the next filtered frame
+// left pending after the last read frame of the stream,
+// e.g., the frames duplicated by the fps filter. It
+// returns false if there are no pending frames.
+func (video *VideoStream) ReadPendingVideoFrame() (*VideoFrame, bool, error) {
+	if video.videoFilter == nil || video.videoFilter.Pending() == 0 {
+		return nil, false, nil
+	}
+
+	video.drainFilter = true
+	frame, ok, err := video.readVideoFrame()
+	video.drainFilter = false
+
+	if err != nil || !ok || frame == nil || video.reorderDepth <= 0 {
+		return frame, ok, err
+	}
+
+	return video.reorder(frame), true, nil
+}
+
+// videoBufferArgs returns the arguments of the
+// buffer source filter for the video frames.
+func videoBufferArgs(width, height, format C.int, timeBase, sar C.AVRational) string {
+	if sar.num <= 0 || sar.den <= 0 {
+		sar.num, sar.den = 0, 1
+	}
+
+	return fmt.Sprintf(
+		"video_size=%dx%d:pix_fmt=%d:time_base=%d/%d:pixel_aspect=%d/%d",
+		width, height, format, timeBase.num, timeBase.den,
+		sar.num, sar.den)
+}
+
+// frameBufferArgs returns the arguments of the
+// buffer source filter for the decoded frame.
+func (video *VideoStream) frameBufferArgs(frame *C.AVFrame) string {
+	return videoBufferArgs(frame.width, frame.height,
+		frame.format, video.inner.time_base,
+		frame.sample_aspect_ratio)
+}
+
+// configure (re)creates the filters of the
+// graph for the specified source arguments.
+func (filter *VideoFilterGraph) configure(srcArgs string) error {
+	filter.freeGraph()
+	graph, err := newFilterGraph(filter.description,
+		"buffer", srcArgs, "buffersink")
+
+	if err != nil {
+		return err
+	}
+
+	filter.graph = graph
+	filter.srcArgs = srcArgs
+
+	return nil
+}
+
+// filterFrame sends the decoded frame to the filter
+// graph and returns the first filtered frame, the
+// others are kept pending. It returns a nil frame
+// if the filters need more frames to produce one.
+func (video *VideoStream) filterFrame(frame *C.AVFrame) (*C.AVFrame, error) {
+	filter := video.videoFilter
+	srcArgs := video.frameBufferArgs(frame)
+
+	// The filter graph is recreated when the
+	// format of the frames changes in the
+	// middle of the stream.
+	if filter.graph == nil || filter.srcArgs != srcArgs {
+		err := filter.configure(srcArgs)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := filter.graph.push(frame)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		filtered, ok, err := filter.graph.pull()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		// The frame of the graph is reused by
+		// the next pull, so keep a reference.
+		clone := C.av_frame_clone(filtered)
+
+		if clone == nil {
+			return nil, fmt.Errorf(
+				"couldn't reference the filtered frame")
+		}
+
+		filter.pending = append(filter.pending, clone)
+	}
+
+	return filter.next(), nil
+}
+
+// next returns the next pending filtered frame
+// or nil if there's none. The frame is valid
+// until the next call.
+func (filter *VideoFilterGraph) next() *C.AVFrame {
+	if filter.current != nil {
+		C.av_frame_free(&filter.current)
+	}
+
+	if len(filter.pending) == 0 {
+		return nil
+	}
+
+	filter.current = filter.pending[0]
+	filter.pending[0] = nil
+	filter.pending = filter.pending[1:]
+
+	return filter.current
+}
+
+// reset drops the pending filtered frames and the
+// state of the filters (e.g., after rewinding).
+func (filter *VideoFilterGraph) reset() {
+	filter.freeGraph()
+
+	for _, frame := range filter.pending {
+		C.av_frame_free(&frame)
+	}
+
+	filter.pending = nil
+
+	if filter.current != nil {
+		C.av_frame_free(&filter.current)
+	}
+}
+
+// freeGraph frees the filters of the graph.
+func (filter *VideoFilterGraph) freeGraph() {
+	if filter.graph != nil {
+		filter.graph.free()
+		filter.graph = nil
+	}
+
+	filter.srcArgs = ""
+}
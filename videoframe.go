@@ -8,37 +8,232 @@ package reisen
 // #include <libswscale/swscale.h>
 // #include <inttypes.h>
 import "C"
-import "image"
+import (
+	"fmt"
+	"image"
+	"time"
+)
 
 // VideoFrame is a single frame
 // of a video stream.
 type VideoFrame struct {
 	baseFrame
-	img *image.RGBA
+	pix           []byte
+	stride        int
+	img           *image.RGBA
+	gray          *image.Gray
+	gray16        *image.Gray16
+	repeatPict    int
+	sarNum        int
+	sarDen        int
+	wallClock     time.Time
+	hasWallClock  bool
+	motionVectors []MotionVector
+	// The raw CEA-608/708 cc_data triplets.
+	closedCaptions []byte
+	hdr10Plus      *HDR10PlusMetadata
+	// The timestamp of the decoded frame
+	// as is, in the codec time base and
+	// the position of its packet.
+	rawPTS     int64
+	codecPTS   int64
+	codecTBNum int
+	codecTBDen int
+	packetPos  int64
 }
 
-// Data returns a byte slice of RGBA
-// pixels of the frame image.
+// Data returns a byte slice of the pixels
+// of the frame image in the output format
+// the stream was opened with (RGBA by default).
 func (frame *VideoFrame) Data() []byte {
-	return frame.img.Pix
+	return frame.pix
 }
 
-// Image returns the RGBA image of the frame.
+// Stride returns the number of bytes between
+// the starts of the adjacent rows of pixels
+// in the data of the frame.
+func (frame *VideoFrame) Stride() int {
+	return frame.stride
+}
+
+// Image returns the RGBA image of the frame
+// or nil if the stream was opened for another
+// output format.
 func (frame *VideoFrame) Image() *image.RGBA {
 	return frame.img
 }
 
+// NRGBA returns the non-premultiplied RGBA image of
+// the frame or nil if the stream was opened for
+// another output format.
+//
+// The RGBA pixels produced by the scaler have straight
+// (non-premultiplied) alpha, so the image shares the
+// pixels of the frame and is correct even if they
+// aren't opaque, e.g., after a filter.
+func (frame *VideoFrame) NRGBA() *image.NRGBA {
+	if frame.img == nil {
+		return nil
+	}
+
+	return &image.NRGBA{
+		Pix:    frame.img.Pix,
+		Stride: frame.img.Stride,
+		Rect:   frame.img.Rect,
+	}
+}
+
+// AnyImage returns the image of the frame of the
+// type matching the output pixel format: *image.RGBA
+// for RGBA, *image.Gray for 8-bit grayscale and
+// *image.Gray16 for 16-bit grayscale. It returns
+// nil for the formats without a matching type
+// (e.g., RGB24).
+func (frame *VideoFrame) AnyImage() image.Image {
+	switch {
+	case frame.img != nil:
+		return frame.img
+
+	case frame.gray != nil:
+		return frame.gray
+
+	case frame.gray16 != nil:
+		return frame.gray16
+
+	default:
+		return nil
+	}
+}
+
+// Gray returns the 8-bit grayscale image
+// of the frame or nil if the stream wasn't
+// opened with OpenDecodeGray.
+func (frame *VideoFrame) Gray() *image.Gray {
+	return frame.gray
+}
+
+// Gray16 returns the 16-bit grayscale image
+// of the frame or nil if the stream wasn't
+// opened with OpenDecodeGray16.
+func (frame *VideoFrame) Gray16() *image.Gray16 {
+	return frame.gray16
+}
+
+// DisplayDuration returns the duration for which
+// the frame should be shown.
+//
+// It accounts for the repeated fields of
+// telecined (pulldown) content: each extra
+// field extends the display time of the
+// frame by a half of the frame duration.
+func (frame *VideoFrame) DisplayDuration() (time.Duration, error) {
+	frNum, frDen := frame.stream.FrameRate()
+
+	if frNum <= 0 || frDen <= 0 {
+		return 0, fmt.Errorf("the frame rate is unknown")
+	}
+
+	tm := float64(frDen) / float64(frNum) *
+		(1 + float64(frame.repeatPict)/2)
+
+	return time.ParseDuration(fmt.Sprintf("%fs", tm))
+}
+
+// AspectRatio returns the fraction of the sample
+// aspect ratio of the frame. It may differ from the
+// one of the stream for the content changing it
+// mid-stream (e.g., concatenated broadcast
+// recordings). The aspect ratio of the stream
+// is returned if the frame doesn't declare it.
+func (frame *VideoFrame) AspectRatio() (int, int) {
+	if frame.sarNum <= 0 || frame.sarDen <= 0 {
+		if video, ok := frame.stream.(*VideoStream); ok {
+			return video.AspectRatio()
+		}
+	}
+
+	return frame.sarNum, frame.sarDen
+}
+
+// PacketPos returns the byte offset of the packet
+// the frame was decoded from in the media file or
+// -1 if it's unknown, e.g., to build an index
+// mapping the time to the byte offset.
+func (frame *VideoFrame) PacketPos() int64 {
+	return frame.packetPos
+}
+
+// PTSInTimeBase returns the timestamp of the
+// frame as decoded (without correcting the
+// wraparounds) and the numerator and the
+// denominator of the stream time base.
+func (frame *VideoFrame) PTSInTimeBase() (int64, int, int) {
+	tbNum, tbDen := frame.stream.TimeBase()
+
+	return frame.rawPTS, tbNum, tbDen
+}
+
+// PTSInCodecTimeBase returns the timestamp of the
+// frame in the time base of the codec and the
+// numerator and the denominator of the time base.
+// The stream time base is used if the codec
+// doesn't declare one.
+func (frame *VideoFrame) PTSInCodecTimeBase() (int64, int, int) {
+	return frame.codecPTS, frame.codecTBNum, frame.codecTBDen
+}
+
+// WallClock returns the absolute UTC time at which
+// the frame was captured. It's only available for
+// live streams mapping their timestamps to the
+// wall-clock time, e.g., RTSP streams with the
+// NTP time of the RTCP sender reports.
+func (frame *VideoFrame) WallClock() (time.Time, bool) {
+	return frame.wallClock, frame.hasWallClock
+}
+
+// reusable tells whether the frame is backed by
+// the specified pixel buffer, so it can be reused
+// for the next frame decoded into the buffer.
+func (frame *VideoFrame) reusable(pix []byte, stride int) bool {
+	return frame != nil && len(frame.pix) == len(pix) &&
+		len(pix) > 0 && &frame.pix[0] == &pix[0] &&
+		frame.stride == stride
+}
+
 // newVideoFrame returns a newly created video frame.
-func newVideoFrame(stream Stream, pts int64, indCoded, indDisplay, width, height int, pix []byte) *VideoFrame {
+func newVideoFrame(stream Stream, pts int64, indCoded, indDisplay, width, height int, format C.enum_AVPixelFormat, stride int, pix []byte) *VideoFrame {
 	upLeft := image.Point{0, 0}
 	lowRight := image.Point{width, height}
-	img := image.NewRGBA(image.Rectangle{upLeft, lowRight})
+	rect := image.Rectangle{upLeft, lowRight}
 	frame := new(VideoFrame)
 
-	img.Pix = pix
+	switch format {
+	case C.AV_PIX_FMT_GRAY8:
+		frame.gray = &image.Gray{
+			Pix:    pix,
+			Stride: stride,
+			Rect:   rect,
+		}
+
+	case C.AV_PIX_FMT_GRAY16BE:
+		frame.gray16 = &image.Gray16{
+			Pix:    pix,
+			Stride: stride,
+			Rect:   rect,
+		}
+
+	case C.AV_PIX_FMT_RGBA:
+		frame.img = &image.RGBA{
+			Pix:    pix,
+			Stride: stride,
+			Rect:   rect,
+		}
+	}
+
 	frame.stream = stream
 	frame.pts = pts
-	frame.img = img
+	frame.pix = pix
+	frame.stride = stride
 	frame.indexCoded = indCoded
 	frame.indexDisplay = indDisplay
 
@@ -0,0 +1,245 @@
+package reisen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// wavHeaderSize is the size of the
+	// canonical WAV header in bytes.
+	wavHeaderSize = 44
+	// wavExtensibleHeaderSize is the size of
+	// the WAVE_FORMAT_EXTENSIBLE header in bytes.
+	wavExtensibleHeaderSize = 68
+
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavFormat describes the PCM samples
+// written to the WAV file.
+type wavFormat struct {
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+	float         bool
+}
+
+// outputWAVFormat returns the format of the
+// samples produced by the audio stream.
+func (audio *AudioStream) outputWAVFormat() wavFormat {
+	sampleFmt := audio.OutputSampleFormat()
+
+	return wavFormat{
+		channels:      audio.OutputChannelCount(),
+		sampleRate:    int(audio.dstRate),
+		bitsPerSample: 8 * sampleFmt.BytesPerSample(),
+		float: sampleFmt == SampleFormatFloat ||
+			sampleFmt == SampleFormatDouble,
+	}
+}
+
+// extensible tells whether the format can't be
+// described by the plain WAV header and requires
+// WAVE_FORMAT_EXTENSIBLE.
+func (format wavFormat) extensible() bool {
+	return format.channels > 2 || format.bitsPerSample > 16
+}
+
+// headerSize returns the size of the WAV header.
+func (format wavFormat) headerSize() int {
+	if format.extensible() {
+		return wavExtensibleHeaderSize
+	}
+
+	return wavHeaderSize
+}
+
+// header returns the WAV header for
+// the specified size of the PCM data.
+func (format wavFormat) header(dataSize uint32) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, format.headerSize()))
+	blockAlign := format.channels * format.bitsPerSample / 8
+	byteRate := format.sampleRate * blockAlign
+	fmtSize := 16
+	tag := wavFormatPCM
+
+	if format.float {
+		tag = wavFormatFloat
+	}
+
+	if format.extensible() {
+		fmtSize = 40
+	}
+
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian,
+		uint32(format.headerSize()-8)+dataSize)
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	binary.Write(buf, binary.LittleEndian, uint32(fmtSize))
+
+	if format.extensible() {
+		binary.Write(buf, binary.LittleEndian, uint16(wavFormatExtensible))
+	} else {
+		binary.Write(buf, binary.LittleEndian, uint16(tag))
+	}
+
+	binary.Write(buf, binary.LittleEndian, uint16(format.channels))
+	binary.Write(buf, binary.LittleEndian, uint32(format.sampleRate))
+	binary.Write(buf, binary.LittleEndian, uint32(byteRate))
+	binary.Write(buf, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(buf, binary.LittleEndian, uint16(format.bitsPerSample))
+
+	if format.extensible() {
+		var mask uint32
+
+		// Assign the first speaker
+		// positions in the standard order.
+		if format.channels < 32 {
+			mask = 1<<uint(format.channels) - 1
+		}
+
+		binary.Write(buf, binary.LittleEndian, uint16(22))
+		binary.Write(buf, binary.LittleEndian, uint16(format.bitsPerSample))
+		binary.Write(buf, binary.LittleEndian, mask)
+		// The sub-format GUID is the format
+		// tag followed by the fixed suffix.
+		binary.Write(buf, binary.LittleEndian, uint16(tag))
+		buf.Write([]byte{
+			0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00,
+			0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71})
+	}
+
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, dataSize)
+
+	return buf.Bytes()
+}
+
+// WriteWAV decodes all the remaining audio
+// frames of the stream and writes them to w
+// as a WAV file with the sample rate, channel
+// count and bit depth of the decoded samples.
+//
+// The media must be opened for decoding and the
+// stream must be opened. If w is an io.WriteSeeker,
+// the samples are written directly and the header
+// is updated in the end, otherwise the samples are
+// buffered in memory until the stream is decoded.
+func (audio *AudioStream) WriteWAV(w io.Writer) error {
+	if audio.OutputSampleFormat().Planar() {
+		return fmt.Errorf(
+			"the planar samples can't be written to a WAV file")
+	}
+
+	format := audio.outputWAVFormat()
+	seeker, seekable := w.(io.WriteSeeker)
+	var start int64
+	var out io.Writer
+	var buf bytes.Buffer
+
+	if seekable {
+		var err error
+		start, err = seeker.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			seekable = false
+		}
+	}
+
+	if seekable {
+		_, err := w.Write(format.header(0))
+
+		if err != nil {
+			return err
+		}
+
+		out = w
+	} else {
+		out = &buf
+	}
+
+	var dataSize int64
+
+	for {
+		pkt, gotPacket, err := audio.media.ReadPacket()
+
+		if err != nil && !isEndOfFile(err) {
+			return err
+		}
+
+		if !gotPacket {
+			break
+		}
+
+		if pkt == nil || pkt.StreamIndex() != audio.Index() {
+			continue
+		}
+
+		frame, gotFrame, err := audio.ReadAudioFrame()
+
+		if err != nil && !isEndOfFile(err) {
+			return err
+		}
+
+		if !gotFrame {
+			break
+		}
+
+		if frame == nil {
+			continue
+		}
+
+		n, err := out.Write(frame.Data())
+		dataSize += int64(n)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if dataSize > int64(^uint32(0))-int64(format.headerSize()) {
+		return fmt.Errorf(
+			"the audio data is too large for a WAV file")
+	}
+
+	if !seekable {
+		_, err := w.Write(format.header(uint32(dataSize)))
+
+		if err != nil {
+			return err
+		}
+
+		_, err = buf.WriteTo(w)
+
+		return err
+	}
+
+	end, err := seeker.Seek(0, io.SeekCurrent)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = seeker.Seek(start, io.SeekStart)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(format.header(uint32(dataSize)))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = seeker.Seek(end, io.SeekStart)
+
+	return err
+}
@@ -0,0 +1,67 @@
+package reisen
+
+// #cgo pkg-config: libavformat
+// #include <libavformat/avformat.h>
+import "C"
+
+// ptsUnwrapper tracks the wraparounds of the
+// timestamps of a stream (e.g., the 33-bit PTS
+// of MPEG-TS wrapping every ~26.5 hours).
+type ptsUnwrapper struct {
+	// The last raw timestamp and the
+	// offset accumulated by the wraps.
+	last   int64
+	offset int64
+	valid  bool
+}
+
+// unwrapPTS returns the timestamp of the decoded
+// frame corrected for the wraparounds of the stream
+// timestamps, so the presentation offsets keep
+// increasing monotonically across the wraps.
+func (stream *baseStream) unwrapPTS(pts int64) int64 {
+	bits := int(stream.inner.pts_wrap_bits)
+
+	if pts == noPTS || bits <= 0 || bits >= 63 {
+		return pts
+	}
+
+	period := int64(1) << uint(bits)
+	unwrap := &stream.unwrap
+
+	if !unwrap.valid {
+		unwrap.last = pts
+		unwrap.valid = true
+
+		return pts + unwrap.offset
+	}
+
+	switch {
+	case pts < unwrap.last-period/2:
+		// The timestamp has wrapped around.
+		unwrap.offset += period
+
+	case pts > unwrap.last+period/2:
+		// A frame preceding the wrap has been
+		// reordered after it (e.g., a B-frame).
+		return pts + unwrap.offset - period
+	}
+
+	unwrap.last = pts
+
+	return pts + unwrap.offset
+}
+
+// resetPTSUnwrap forgets the timestamp wraps tracked
+// for all the streams when the media is rewound.
+func (media *Media) resetPTSUnwrap() {
+	for _, stream := range media.streams {
+		switch s := stream.(type) {
+		case *VideoStream:
+			s.unwrap = ptsUnwrapper{}
+
+		case *AudioStream:
+			s.unwrap = ptsUnwrapper{}
+		}
+	}
+}
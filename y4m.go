@@ -0,0 +1,175 @@
+package reisen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// y4mFormat is the chroma tag of a pixel
+// format in the YUV4MPEG2 stream header and
+// the number of bytes per sample.
+type y4mFormat struct {
+	colorspace     string
+	bytesPerSample int
+}
+
+// y4mFormats maps the names of the pixel
+// formats to their YUV4MPEG2 descriptions.
+var y4mFormats = map[string]y4mFormat{
+	"yuv420p":     {"420jpeg", 1},
+	"yuvj420p":    {"420jpeg", 1},
+	"yuv422p":     {"422", 1},
+	"yuvj422p":    {"422", 1},
+	"yuv444p":     {"444", 1},
+	"yuvj444p":    {"444", 1},
+	"yuv420p9le":  {"420p9", 2},
+	"yuv422p9le":  {"422p9", 2},
+	"yuv444p9le":  {"444p9", 2},
+	"yuv420p10le": {"420p10", 2},
+	"yuv422p10le": {"422p10", 2},
+	"yuv444p10le": {"444p10", 2},
+	"yuv420p12le": {"420p12", 2},
+	"yuv422p12le": {"422p12", 2},
+	"yuv444p12le": {"444p12", 2},
+	"yuv420p14le": {"420p14", 2},
+	"yuv422p14le": {"422p14", 2},
+	"yuv444p14le": {"444p14", 2},
+	"yuv420p16le": {"420p16", 2},
+	"yuv422p16le": {"422p16", 2},
+	"yuv444p16le": {"444p16", 2},
+}
+
+// DumpY4M decodes the specified number of the
+// following video frames of the stream (all the
+// remaining ones if frames <= 0) and writes them
+// to w as a YUV4MPEG2 stream, so the decoder output
+// can be inspected with other tools (e.g., ffplay).
+//
+// The media must be opened for decoding and
+// the stream must be opened with OpenYUV.
+func (video *VideoStream) DumpY4M(w io.Writer, frames int) error {
+	if !video.yuvOnly {
+		return fmt.Errorf(
+			"the stream must be opened with OpenYUV")
+	}
+
+	out := bufio.NewWriter(w)
+	written := 0
+	var format y4mFormat
+	var width, height int
+
+	for frames <= 0 || written < frames {
+		pkt, gotPacket, err := video.media.ReadPacket()
+
+		if err != nil && !isEndOfFile(err) {
+			return err
+		}
+
+		if !gotPacket {
+			break
+		}
+
+		if pkt == nil || pkt.StreamIndex() != video.Index() {
+			continue
+		}
+
+		frame, gotFrame, err := video.ReadYUVFrame()
+
+		if err != nil && !isEndOfFile(err) {
+			return err
+		}
+
+		if !gotFrame {
+			break
+		}
+
+		if frame == nil {
+			continue
+		}
+
+		if written == 0 {
+			var ok bool
+			format, ok = y4mFormats[frame.PixelFormat().String()]
+
+			if !ok {
+				return fmt.Errorf(
+					"the pixel format %s is not supported by YUV4MPEG2",
+					frame.PixelFormat())
+			}
+
+			width, height = frame.Size()
+			_, err = out.WriteString(video.y4mHeader(
+				width, height, format))
+
+			if err != nil {
+				return err
+			}
+		} else if frameWidth, frameHeight := frame.Size(); frameWidth != width ||
+			frameHeight != height {
+			return fmt.Errorf(
+				"the frame size changed from %dx%d to %dx%d",
+				width, height, frameWidth, frameHeight)
+		}
+
+		err = writeY4MFrame(out, frame, format)
+
+		if err != nil {
+			return err
+		}
+
+		written++
+	}
+
+	return out.Flush()
+}
+
+// y4mHeader returns the header of the YUV4MPEG2
+// stream of the frames of the specified size.
+func (video *VideoStream) y4mHeader(width, height int, format y4mFormat) string {
+	frNum, frDen := video.FrameRate()
+	sarNum, sarDen := video.AspectRatio()
+
+	if frNum <= 0 || frDen <= 0 {
+		frNum, frDen = 25, 1
+	}
+
+	if sarNum <= 0 || sarDen <= 0 {
+		sarNum, sarDen = 0, 0
+	}
+
+	return fmt.Sprintf("YUV4MPEG2 W%d H%d F%d:%d Ip A%d:%d C%s\n",
+		width, height, frNum, frDen, sarNum, sarDen,
+		format.colorspace)
+}
+
+// writeY4MFrame writes the planes of the frame
+// without the row padding to the YUV4MPEG2 stream.
+func writeY4MFrame(w *bufio.Writer, frame *YUVFrame, format y4mFormat) error {
+	_, err := w.WriteString("FRAME\n")
+
+	if err != nil {
+		return err
+	}
+
+	width, height := frame.Size()
+	chromaWidth, chromaHeight := frame.ChromaSize()
+	planes := [3][]byte{frame.Y(), frame.U(), frame.V()}
+	widths := [3]int{width, chromaWidth, chromaWidth}
+	heights := [3]int{height, chromaHeight, chromaHeight}
+
+	for i, plane := range planes {
+		linesize := frame.linesizes[i]
+		rowSize := widths[i] * format.bytesPerSample
+
+		for y := 0; y < heights[i]; y++ {
+			_, err = w.Write(plane[y*linesize : y*linesize+rowSize])
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
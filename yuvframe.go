@@ -0,0 +1,158 @@
+package reisen
+
+// #cgo pkg-config: libavutil
+// #include <libavutil/frame.h>
+// #include <libavutil/pixdesc.h>
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// YUVFrame is a video frame with the planar
+// YUV pixels of the decoder output without
+// any conversion.
+type YUVFrame struct {
+	baseFrame
+	data         []byte
+	planes       [3][]byte
+	linesizes    [3]int
+	width        int
+	height       int
+	pixFmt       PixelFormat
+	chromaWidth  int
+	chromaHeight int
+}
+
+// Data returns the Y, U and V planes
+// of the frame laid out one after another.
+func (frame *YUVFrame) Data() []byte {
+	return frame.data
+}
+
+// Y returns the luma plane of the frame.
+func (frame *YUVFrame) Y() []byte {
+	return frame.planes[0]
+}
+
+// U returns the blue-difference
+// chroma plane of the frame.
+func (frame *YUVFrame) U() []byte {
+	return frame.planes[1]
+}
+
+// V returns the red-difference
+// chroma plane of the frame.
+func (frame *YUVFrame) V() []byte {
+	return frame.planes[2]
+}
+
+// Linesizes returns the number of bytes between the
+// starts of the adjacent rows of the Y, U and V planes.
+func (frame *YUVFrame) Linesizes() (int, int, int) {
+	return frame.linesizes[0],
+		frame.linesizes[1], frame.linesizes[2]
+}
+
+// Size returns the width and the
+// height of the luma plane.
+func (frame *YUVFrame) Size() (int, int) {
+	return frame.width, frame.height
+}
+
+// ChromaSize returns the width and the height
+// of the chroma planes reduced by the chroma
+// subsampling (e.g., halved for YUV420P).
+func (frame *YUVFrame) ChromaSize() (int, int) {
+	return frame.chromaWidth, frame.chromaHeight
+}
+
+// PixelFormat returns the pixel format of the
+// frame (e.g., yuv420p or yuv422p10le).
+func (frame *YUVFrame) PixelFormat() PixelFormat {
+	return frame.pixFmt
+}
+
+// OpenYUV opens the video stream for decoding frames
+// in the planar YUV format of the decoder without
+// converting them. The frames are obtained with
+// ReadYUVFrame, no scaler is allocated.
+func (video *VideoStream) OpenYUV() error {
+	err := video.open()
+
+	if err != nil {
+		return err
+	}
+
+	video.outFormat = C.AV_PIX_FMT_NONE
+	video.yuvOnly = true
+
+	return nil
+}
+
+// ReadYUVFrame reads the next video frame from the
+// stream with the planes of the YUV pixels as decoded.
+//
+// It fails if the frames aren't in a planar YUV
+// format with three separate planes.
+func (video *VideoStream) ReadYUVFrame() (*YUVFrame, bool, error) {
+	if video.coverArtRead {
+		return nil, false, nil
+	}
+
+	src, ok, err := video.decodeFrame()
+
+	if err != nil || !ok || src == nil {
+		return nil, ok, err
+	}
+
+	format := C.enum_AVPixelFormat(src.format)
+	desc := C.av_pix_fmt_desc_get(format)
+
+	if desc == nil || desc.flags&C.AV_PIX_FMT_FLAG_PLANAR == 0 ||
+		desc.flags&(C.AV_PIX_FMT_FLAG_RGB|C.AV_PIX_FMT_FLAG_HWACCEL) != 0 ||
+		desc.nb_components < 3 || C.av_pix_fmt_count_planes(format) != 3 {
+		return nil, false, fmt.Errorf(
+			"the pixel format %s is not planar YUV",
+			pixelFormatName(format))
+	}
+
+	width := int(src.width)
+	height := int(src.height)
+	// Round up for odd sizes.
+	chromaWidth := -(-width >> uint(desc.log2_chroma_w))
+	chromaHeight := -(-height >> uint(desc.log2_chroma_h))
+	heights := [3]int{height, chromaHeight, chromaHeight}
+	total := 0
+
+	for i := range heights {
+		total += int(src.linesize[i]) * heights[i]
+	}
+
+	frame := &YUVFrame{
+		data:         make([]byte, 0, total),
+		width:        width,
+		height:       height,
+		pixFmt:       PixelFormat(format),
+		chromaWidth:  chromaWidth,
+		chromaHeight: chromaHeight,
+	}
+
+	for i := range heights {
+		size := int(src.linesize[i]) * heights[i]
+		plane := unsafe.Slice((*byte)(unsafe.Pointer(src.data[i])), size)
+		start := len(frame.data)
+
+		frame.data = append(frame.data, plane...)
+		frame.planes[i] = frame.data[start:len(frame.data):len(frame.data)]
+		frame.linesizes[i] = int(src.linesize[i])
+	}
+
+	frame.stream = video
+	frame.pts = video.unwrapPTS(int64(src.pts))
+	frame.indexCoded = int(src.coded_picture_number)
+	frame.indexDisplay = int(src.display_picture_number)
+	video.coverArtRead = video.IsCoverArt()
+
+	return frame, true, nil
+}